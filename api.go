@@ -0,0 +1,219 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/linode/linodego"
+)
+
+// The methods in this file wrap every linodego call the provider makes, so that each of them is retried according
+// to Provider.Retry. Errors are returned as linodego returns them; callers classify them.
+
+func (p *Provider) apiListDomains(ctx context.Context, opts *linodego.ListOptions) ([]linodego.Domain, error) {
+	return withRetry(ctx, p, "ListDomains", nil, func(ctx context.Context) ([]linodego.Domain, error) {
+		return p.client.ListDomains(ctx, opts)
+	})
+}
+
+func (p *Provider) apiGetDomain(ctx context.Context, domainID int) (*linodego.Domain, error) {
+	return withRetry(ctx, p, "GetDomain", nil, func(ctx context.Context) (*linodego.Domain, error) {
+		return p.client.GetDomain(ctx, domainID)
+	})
+}
+
+// apiCreateDomain creates a domain. If a failed attempt may have created it anyway, the domain is looked up by name
+// before trying again, because Linode would reject the retry as a duplicate.
+func (p *Provider) apiCreateDomain(ctx context.Context, opts linodego.DomainCreateOptions) (*linodego.Domain, error) {
+	tookEffect := func(ctx context.Context) (*linodego.Domain, bool, error) {
+		f := linodego.Filter{}
+		f.AddField(linodego.Eq, "domain", opts.Domain)
+		filter, err := f.MarshalJSON()
+		if err != nil {
+			return nil, false, err
+		}
+		domains, err := p.listAllDomains(ctx, string(filter))
+		if err != nil || len(domains) != 1 {
+			return nil, false, err
+		}
+		return &domains[0], true, nil
+	}
+	return withRetry(ctx, p, "CreateDomain", tookEffect, func(ctx context.Context) (*linodego.Domain, error) {
+		return p.client.CreateDomain(ctx, opts)
+	})
+}
+
+func (p *Provider) apiUpdateDomain(ctx context.Context, domainID int, opts linodego.DomainUpdateOptions) (*linodego.Domain, error) {
+	return withRetry(ctx, p, "UpdateDomain", nil, func(ctx context.Context) (*linodego.Domain, error) {
+		return p.client.UpdateDomain(ctx, domainID, opts)
+	})
+}
+
+func (p *Provider) apiDeleteDomain(ctx context.Context, domainID int) error {
+	tookEffect := func(ctx context.Context) (struct{}, bool, error) {
+		if err := p.waitForRateLimit(ctx); err != nil {
+			return struct{}{}, false, err
+		}
+		_, err := p.client.GetDomain(ctx, domainID)
+		switch {
+		case err == nil:
+			return struct{}{}, false, nil
+		case apiStatusCode(err) == http.StatusNotFound:
+			return struct{}{}, true, nil
+		default:
+			return struct{}{}, false, err
+		}
+	}
+	_, err := withRetry(ctx, p, "DeleteDomain", tookEffect, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, p.client.DeleteDomain(ctx, domainID)
+	})
+	return err
+}
+
+func (p *Provider) apiListDomainRecords(ctx context.Context, domainID int, opts *linodego.ListOptions) ([]linodego.DomainRecord, error) {
+	return withRetry(ctx, p, "ListDomainRecords", nil, func(ctx context.Context) ([]linodego.DomainRecord, error) {
+		return p.client.ListDomainRecords(ctx, domainID, opts)
+	})
+}
+
+// apiCreateDomainRecord creates a record. If a failed attempt may have created it anyway, the domain is searched for
+// an identical record before trying again, so that the record is not created twice. For types that allow identical
+// records, such as TXT, an identical record that already existed is taken for the created one.
+func (p *Provider) apiCreateDomainRecord(ctx context.Context, domainID int, opts linodego.DomainRecordCreateOptions) (*linodego.DomainRecord, error) {
+	tookEffect := func(ctx context.Context) (*linodego.DomainRecord, bool, error) {
+		f := linodego.Filter{}
+		if opts.Type != linodego.RecordTypeSRV {
+			// Linode derives the name of SRV records from their service and protocol
+			f.AddField(linodego.Eq, "name", opts.Name)
+		}
+		f.AddField(linodego.Eq, "type", opts.Type)
+		filter, err := f.MarshalJSON()
+		if err != nil {
+			return nil, false, err
+		}
+		records, err := p.listAllDomainRecords(ctx, domainID, string(filter))
+		if err != nil {
+			return nil, false, err
+		}
+		for _, record := range records {
+			if recordMatchesOptions(record, opts) {
+				return &record, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+	return withRetry(ctx, p, "CreateDomainRecord", tookEffect, func(ctx context.Context) (*linodego.DomainRecord, error) {
+		return p.client.CreateDomainRecord(ctx, domainID, opts)
+	})
+}
+
+func (p *Provider) apiUpdateDomainRecord(ctx context.Context, domainID, recordID int, opts linodego.DomainRecordUpdateOptions) (*linodego.DomainRecord, error) {
+	return withRetry(ctx, p, "UpdateDomainRecord", nil, func(ctx context.Context) (*linodego.DomainRecord, error) {
+		return p.client.UpdateDomainRecord(ctx, domainID, recordID, opts)
+	})
+}
+
+func (p *Provider) apiDeleteDomainRecord(ctx context.Context, domainID, recordID int) error {
+	tookEffect := func(ctx context.Context) (struct{}, bool, error) {
+		if err := p.waitForRateLimit(ctx); err != nil {
+			return struct{}{}, false, err
+		}
+		_, err := p.client.GetDomainRecord(ctx, domainID, recordID)
+		switch {
+		case err == nil:
+			return struct{}{}, false, nil
+		case apiStatusCode(err) == http.StatusNotFound:
+			return struct{}{}, true, nil
+		default:
+			return struct{}{}, false, err
+		}
+	}
+	_, err := withRetry(ctx, p, "DeleteDomainRecord", tookEffect, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, p.client.DeleteDomainRecord(ctx, domainID, recordID)
+	})
+	return err
+}
+
+// apiGetProfile returns the profile of the token's user together with the OAuth scopes of the token, which Linode
+// only reports in the X-OAuth-Scopes response header.
+func (p *Provider) apiGetProfile(ctx context.Context) (*linodego.Profile, string, error) {
+	profile, header, err := apiGet[linodego.Profile](ctx, p, "GetProfile", "profile")
+	if err != nil {
+		return nil, "", err
+	}
+	return profile, header.Get("X-OAuth-Scopes"), nil
+}
+
+// apiGetProfileGrants returns the grants of the token's user, or nil if the user is unrestricted.
+func (p *Provider) apiGetProfileGrants(ctx context.Context) (*linodego.UserGrants, error) {
+	grants, _, err := apiGet[linodego.UserGrants](ctx, p, "GetProfileGrants", "profile/grants")
+	return grants, err
+}
+
+// apiGet gets the API path into a T, for endpoints linodego does not expose with the response headers or at all.
+// The result is nil if Linode responds with 204 No Content.
+func apiGet[T any](ctx context.Context, p *Provider, op, path string) (*T, http.Header, error) {
+	type response struct {
+		result *T
+		header http.Header
+	}
+	resp, err := withRetry(ctx, p, op, nil, func(ctx context.Context) (response, error) {
+		result := new(T)
+		resp, err := p.client.R(ctx).SetResult(result).Get(path)
+		if err != nil {
+			return response{}, linodego.NewError(err)
+		}
+		if resp.IsError() {
+			apiErr := linodego.NewError(resp)
+			if apiErr.Code == linodego.ErrorUnsupported {
+				// The error response had no Linode error body, e.g. it came from a proxy
+				apiErr = &linodego.Error{Code: resp.StatusCode(), Message: resp.Status(), Response: resp.RawResponse}
+			}
+			return response{}, apiErr
+		}
+		if resp.StatusCode() == http.StatusNoContent {
+			result = nil
+		}
+		return response{result: result, header: resp.Header()}, nil
+	})
+	return resp.result, resp.header, err
+}
+
+// recordMatchesOptions reports whether an existing record is what creating a record with opts would produce. The TTL
+// is not compared, because Linode rounds it to the values it supports.
+func recordMatchesOptions(record linodego.DomainRecord, opts linodego.DomainRecordCreateOptions) bool {
+	existing := createOptionsFromDomainRecord(record)
+	if existing.Type != opts.Type {
+		return false
+	}
+	if opts.Type != linodego.RecordTypeSRV && !strings.EqualFold(existing.Name, opts.Name) {
+		return false
+	}
+	switch opts.Type {
+	case linodego.RecordTypeTXT, linodego.RecordTypeCAA:
+		if existing.Target != opts.Target {
+			return false
+		}
+	default:
+		// Host names are compared case-insensitively and with or without the trailing dot
+		if !strings.EqualFold(strings.TrimSuffix(existing.Target, "."), strings.TrimSuffix(opts.Target, ".")) {
+			return false
+		}
+	}
+	return deref(existing.Priority) == deref(opts.Priority) &&
+		deref(existing.Weight) == deref(opts.Weight) &&
+		deref(existing.Port) == deref(opts.Port) &&
+		deref(existing.Service) == deref(opts.Service) &&
+		deref(existing.Protocol) == deref(opts.Protocol) &&
+		deref(existing.Tag) == deref(opts.Tag)
+}
+
+// deref returns the value v points to, or the zero value if v is nil.
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
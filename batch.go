@@ -0,0 +1,39 @@
+package linode
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// runConcurrently calls fn for every index from 0 to n-1, with up to Provider.Workers calls in flight at a time, and
+// returns the error of each call at its index. Calls are started in index order. If failFast is set, no further calls
+// are started once one has failed; calls already in flight are left to finish, so that their outcome is known. fn
+// must only write to state owned by its index.
+func (p *Provider) runConcurrently(n int, failFast bool, fn func(i int) error) []error {
+	errs := make([]error, n)
+	workers := min(max(p.Workers, 1), n)
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if failFast && failed.Load() {
+					return
+				}
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					errs[i] = err
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
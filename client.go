@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/netip"
 	"strings"
 	"time"
@@ -15,50 +16,171 @@ import (
 
 var ErrUnsupportedType = errors.New("Unsupported DNS record type")
 
+var (
+	// ErrZoneNotFound is returned when no Linode domain matches the zone.
+	ErrZoneNotFound = errors.New("zone not found")
+	// ErrAmbiguousZone is returned when more than one Linode domain matches the zone.
+	ErrAmbiguousZone = errors.New("zone is ambiguous")
+	// ErrAuthentication is returned when Linode rejects the API token (HTTP 401 or 403).
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrRateLimited is returned when Linode rate limits the request (HTTP 429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNoCredentials is returned when no API token is configured, neither on the Provider nor in the environment.
+	ErrNoCredentials = errors.New("no Linode API credentials")
+	// ErrInsufficientPermissions is reported by Validate when the token or its user may not manage a zone's records.
+	ErrInsufficientPermissions = errors.New("insufficient permissions")
+	// ErrPlanChanged is returned by SyncZone when the zone no longer matches the plan passed in SyncOptions.Reviewed.
+	ErrPlanChanged = errors.New("sync plan changed since it was reviewed")
+)
+
+// RecordError is the failure to apply a single record.
+type RecordError struct {
+	Record libdns.Record
+	Err    error
+}
+
+func (e RecordError) Error() string {
+	rr := e.Record.RR()
+	return fmt.Sprintf("record (%s %s %q): %v", rr.Name, rr.Type, rr.Data, e.Err)
+}
+
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// PartialFailureError is returned when only some of the records could be applied. Each failed record is listed with
+// its cause; errors.Is and errors.As look through all of them.
+type PartialFailureError struct {
+	Failed []RecordError
+}
+
+func (e *PartialFailureError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, failed := range e.Failed {
+		msgs = append(msgs, failed.Error())
+	}
+	return fmt.Sprintf("%d record(s) failed: %s", len(e.Failed), strings.Join(msgs, "; "))
+}
+
+func (e *PartialFailureError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, failed := range e.Failed {
+		errs = append(errs, failed)
+	}
+	return errs
+}
+
+// apiStatusCode returns the HTTP status code of an error returned by linodego, or 0 if there is none.
+func apiStatusCode(err error) int {
+	// linodego returns its Error both by value and by pointer
+	var ptrErr *linodego.Error
+	if errors.As(err, &ptrErr) {
+		return ptrErr.Code
+	}
+	var valErr linodego.Error
+	if errors.As(err, &valErr) {
+		return valErr.Code
+	}
+	return 0
+}
+
+// classifyAPIError wraps an error returned by linodego with the matching sentinel error, if any, so that callers can
+// use errors.Is.
+func classifyAPIError(err error) error {
+	switch apiStatusCode(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAuthentication, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return err
+}
+
 func (p *Provider) getDomainIDByZone(ctx context.Context, zone string) (int, error) {
-	slog.Debug("Enter getDomainIDByZone", "zone", zone)
+	p.logger.Debug("Enter getDomainIDByZone", "zone", zone)
+	ttl := p.zoneCacheTTL()
+	if ttl > 0 {
+		if domainID, ok := p.zoneCache.get(zone); ok {
+			p.logger.Debug("Exit getDomainIDByZone", "zone", zone, "domainID", domainID, "cached", true)
+			return domainID, nil
+		}
+	}
 	f := linodego.Filter{}
 	// Trim the trailing dot from the zone name because Linode seems to require it
-	f.AddField(linodego.Eq, "domain", strings.TrimSuffix(libdns.AbsoluteName("@", zone), "."))
+	f.AddField(linodego.Eq, "domain", domainName(zone))
 	filter, err := f.MarshalJSON()
 	if err != nil {
 		return 0, fmt.Errorf("failed to marshal filter: %w", err)
 	}
-	listOptions := linodego.NewListOptions(0, string(filter))
-	domains, err := p.client.ListDomains(ctx, listOptions)
+	domains, err := p.listAllDomains(ctx, string(filter))
 	if err != nil {
 		return 0, fmt.Errorf("could not list domains: %w", err)
 	}
 	if len(domains) == 0 {
-		return 0, fmt.Errorf("could not find the domain: 0 returned")
+		return 0, fmt.Errorf("could not find the domain: 0 returned: %w", ErrZoneNotFound)
 	}
 	if len(domains) > 1 {
-		return 0, fmt.Errorf("could not find the domain: >1 returned: [%v]", domains)
+		return 0, fmt.Errorf("could not find the domain: >1 returned: [%v]: %w", domains, ErrAmbiguousZone)
 	}
-	slog.Debug("Exit getDomainIDByZone", "zone", zone, "domainID", domains[0].ID)
+	if ttl > 0 {
+		p.zoneCache.put(zone, domains[0].ID, ttl)
+	}
+	p.logger.Debug("Exit getDomainIDByZone", "zone", zone, "domainID", domains[0].ID)
 	return domains[0].ID, nil
 }
 
+// forgetZoneIfGone drops the cached domain ID of the zone when err shows that Linode no longer knows the domain.
+func (p *Provider) forgetZoneIfGone(zone string, err error) {
+	if apiStatusCode(err) == http.StatusNotFound {
+		p.logger.Debug("forgetting cached domain ID", "zone", zone, "error", err)
+		p.zoneCache.invalidate(zone)
+	}
+}
+
 func (p *Provider) listDomainRecords(ctx context.Context, domainID int) ([]libdns.Record, error) {
-	slog.Debug("Enter listDomainRecords", "domainID", domainID)
-	linodeRecords, err := p.client.ListDomainRecords(ctx, domainID, nil)
+	p.logger.Debug("Enter listDomainRecords", "domainID", domainID)
+	records := make([]libdns.Record, 0)
+	err := p.iterateDomainRecords(ctx, domainID, func(record libdns.Record) error {
+		records = append(records, record)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not list domain records: %w", err)
+		return nil, err
 	}
-	records := make([]libdns.Record, 0, len(linodeRecords))
-	for _, linodeRecord := range linodeRecords {
-		record, err := convertToLibdns(&linodeRecord)
-		if err != nil {
-			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+	p.logger.Debug("Exit listDomainRecords", "domainID", domainID, "lenRecords", len(records))
+	return records, nil
+}
+
+// iterateDomainRecords calls fn for every record of the domain, one page of records at a time. An error returned by
+// fn stops the iteration and is returned as-is.
+func (p *Provider) iterateDomainRecords(ctx context.Context, domainID int, fn func(libdns.Record) error) error {
+	list := func(ctx context.Context, opts *linodego.ListOptions) ([]linodego.DomainRecord, error) {
+		return p.apiListDomainRecords(ctx, domainID, opts)
+	}
+	var stopErr error
+	err := forEachPage(ctx, p, "", list, func(page []linodego.DomainRecord) error {
+		for _, linodeRecord := range page {
+			record, err := convertToLibdns(p.logger, &linodeRecord)
+			if err != nil {
+				return fmt.Errorf("could not convert record to libdns struct: %w", err)
+			}
+			if stopErr = fn(record); stopErr != nil {
+				return stopErr
+			}
 		}
-		records = append(records, record)
+		return nil
+	})
+	if stopErr != nil {
+		return stopErr
 	}
-	slog.Debug("Exit listDomainRecords", "domainID", domainID, "lenRecords", len(records))
-	return records, nil
+	if err != nil {
+		return fmt.Errorf("could not list domain records: %w", err)
+	}
+	return nil
 }
 
 func (p *Provider) createOrUpdateDomainRecords(ctx context.Context, zone string, domainID int, records []libdns.Record) ([]libdns.Record, error) {
-	slog.Debug("Enter createOrUpdateDomainRecords", "zone", zone, "domainID", domainID, "lenRecords", len(records))
+	p.logger.Debug("Enter createOrUpdateDomainRecords", "zone", zone, "domainID", domainID, "lenRecords", len(records))
 	// According to the libdns interface, any (Name, Type) pairs in the input records should be the only records that
 	// remain in the output for those (Name, Type) pairs.
 	// Ex: (lifted from the libdns interface and annotated)
@@ -93,71 +215,361 @@ func (p *Provider) createOrUpdateDomainRecords(ctx context.Context, zone string,
 	//	;; Resultant zone
 	//	alpha.example.com. 3600 IN AAAA 2001:db8::1 (unchanged, present in input)
 	//	alpha.example.com. 3600 IN AAAA 2001:db8::2 (unchanged, present in input)
-	//	alpha.example.com. 3600 IN AAAA 2001:db8::5 (updated)
+	//	alpha.example.com. 3600 IN AAAA 2001:db8::5 (created)
 	//	beta.example.com.  3600 IN AAAA 2001:db8::3 (unchanged, not present in input)
 	//	beta.example.com.  3600 IN AAAA 2001:db8::4 (unchanged, not present in input)
-	setRecords := make([]libdns.Record, 0, len(records))
+	//
+	// Rather than deleting every matching record and recreating the input, the existing records are diffed against
+	// the input: identical records are kept, changed records are updated in place, and only the leftovers are created
+	// or deleted. Deletions happen last so that a name being reconciled never resolves to nothing.
 
-	// First, make a map of (Name, Type) pairs from the input records
-	pairs := make(map[string]map[string]struct{})
+	// First, make a set of (Name, Type) pairs from the input records
+	pairs := make(map[rrsetKey]struct{})
 	for _, rec := range records {
-		rr := rec.RR()
-		// Set value for (Name, Type) pair
-		pairs[rr.Name] = make(map[string]struct{})
-		pairs[rr.Name][rr.Type] = struct{}{}
+		pairs[rrsetKeyOf(rec.RR())] = struct{}{}
 	}
 
-	// Fetch existing records to determine which to delete
+	// Fetch existing records to determine which to keep, update, or delete
 	// Use linode API (not libdns) to keep the record ID
-	existingRecords, err := p.client.ListDomainRecords(ctx, domainID, nil)
+	existingRecords, err := p.listAllDomainRecords(ctx, domainID, "")
 	if err != nil {
 		return nil, fmt.Errorf("could not list domain records: %w", err)
 	}
 
-	// Delete any records that match the (Name, Type) pairs in the input
-	for _, record := range existingRecords {
-		libRecord, err := convertToLibdns(&record)
+	plan, err := p.planRecordChanges(existingRecords, records, func(rr libdns.RR) bool {
+		_, ok := pairs[rrsetKeyOf(rr)]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setRecords, err := p.applyRecordPlanOrRollBack(ctx, zone, domainID, records, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Debug("Exit createOrUpdateDomainRecords", "zone", zone, "domainID", domainID, "lenSetRecords", len(setRecords))
+	return setRecords, nil
+}
+
+// rrsetKey identifies an RRset, i.e. all the records of a zone sharing the same (Name, Type) pair. The name is in
+// ASCII and lower case, so that names differing only in case or IDN form are the same.
+type rrsetKey struct {
+	name string
+	typ  string
+}
+
+func rrsetKeyOf(rr libdns.RR) rrsetKey {
+	return rrsetKey{name: asciiName(rr.Name), typ: rr.Type}
+}
+
+// sameRecord reports whether two records are identical apart from the case and IDN form of their names.
+func sameRecord(a, b libdns.RR) bool {
+	return rrsetKeyOf(a) == rrsetKeyOf(b) && a.TTL == b.TTL && a.Data == b.Data
+}
+
+// linodeTTLs are the record TTLs Linode supports, in seconds. It rounds any other TTL up to the next one.
+var linodeTTLs = []int{30, 120, 300, 3600, 7200, 14400, 28800, 57600, 86400, 172800, 345600, 604800, 1209600, 2419200}
+
+// linodeTTL returns the TTL Linode stores for a record created with ttl. 0 stays 0, the default TTL of the domain.
+func linodeTTL(ttl time.Duration) time.Duration {
+	seconds := int(ttl.Seconds())
+	if seconds <= 0 {
+		return 0
+	}
+	for _, supported := range linodeTTLs {
+		if seconds <= supported {
+			return time.Duration(supported) * time.Second
+		}
+	}
+	return time.Duration(linodeTTLs[len(linodeTTLs)-1]) * time.Second
+}
+
+// withLinodeTTL returns the record with the TTL Linode would store for it, keeping its type where possible.
+func withLinodeTTL(record libdns.Record) libdns.Record {
+	rr := record.RR()
+	ttl := linodeTTL(rr.TTL)
+	if ttl == rr.TTL {
+		return record
+	}
+	rr.TTL = ttl
+	parsed, err := rr.Parse()
+	if err != nil {
+		return rr
+	}
+	return parsed
+}
+
+// plannedRecord pairs an index into the desired records with the existing Linode record it is reconciled against.
+type plannedRecord struct {
+	index    int
+	existing linodego.DomainRecord
+}
+
+// recordPlan is the set of changes needed to make the existing records of a zone match the desired records.
+type recordPlan struct {
+	// unchanged are desired records that already exist exactly as requested.
+	unchanged []plannedRecord
+	// updates are desired records that will overwrite an existing record of the same (Name, Type) in place.
+	updates []plannedRecord
+	// creates are indexes of desired records that have no existing record to reuse.
+	creates []int
+	// deletes are existing records in scope that are not wanted anymore.
+	deletes []linodego.DomainRecord
+}
+
+// planRecordChanges diffs the existing records against the desired records. Only existing records for which inScope
+// returns true are considered; everything else is left alone. Within a (Name, Type) pair, identical records are kept,
+// the remaining existing records are reused for the remaining desired records, and whatever is left over on either
+// side is created or deleted.
+func (p *Provider) planRecordChanges(existing []linodego.DomainRecord, desired []libdns.Record, inScope func(libdns.RR) bool) (recordPlan, error) {
+	p.logger.Debug("Enter planRecordChanges", "lenExisting", len(existing), "lenDesired", len(desired))
+	type candidate struct {
+		record linodego.DomainRecord
+		rr     libdns.RR
+		used   bool
+	}
+	// Group the existing records in scope by (Name, Type) pair
+	groups := make(map[rrsetKey][]*candidate)
+	candidates := make([]*candidate, 0, len(existing))
+	for _, record := range existing {
+		libRecord, err := convertToLibdns(p.logger, &record)
 		if err != nil {
-			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+			return recordPlan{}, fmt.Errorf("could not convert record to libdns struct: %w", err)
 		}
 		rr := libRecord.RR()
-		if _, ok := pairs[rr.Name]; ok {
-			if _, ok := pairs[rr.Name][rr.Type]; ok {
-				// Existing record matches (Name, Type) pair in input; delete it
-				if err := p.client.DeleteDomainRecord(ctx, domainID, record.ID); err != nil {
-					return setRecords, fmt.Errorf("could not delete domain record %d: %w", record.ID, err)
-				}
+		if !inScope(rr) {
+			continue
+		}
+		c := &candidate{record: record, rr: rr}
+		key := rrsetKeyOf(rr)
+		groups[key] = append(groups[key], c)
+		candidates = append(candidates, c)
+	}
+
+	plan := recordPlan{}
+	matched := make([]bool, len(desired))
+	// Keep existing records that are identical to a desired record
+	for i, record := range desired {
+		rr := record.RR()
+		for _, c := range groups[rrsetKeyOf(rr)] {
+			if !c.used && sameRecord(c.rr, rr) {
+				c.used = true
+				matched[i] = true
+				plan.unchanged = append(plan.unchanged, plannedRecord{index: i, existing: c.record})
+				break
 			}
 		}
 	}
+	// Reuse the remaining existing records of the same (Name, Type) pair, otherwise create a new record
+	for i, record := range desired {
+		if matched[i] {
+			continue
+		}
+		rr := record.RR()
+		reused := false
+		for _, c := range groups[rrsetKeyOf(rr)] {
+			if !c.used {
+				c.used = true
+				reused = true
+				plan.updates = append(plan.updates, plannedRecord{index: i, existing: c.record})
+				break
+			}
+		}
+		if !reused {
+			plan.creates = append(plan.creates, i)
+		}
+	}
+	// Delete whatever is left over
+	for _, c := range candidates {
+		if !c.used {
+			plan.deletes = append(plan.deletes, c.record)
+		}
+	}
+	p.logger.Debug("Exit planRecordChanges", "lenUnchanged", len(plan.unchanged), "lenUpdates", len(plan.updates),
+		"lenCreates", len(plan.creates), "lenDeletes", len(plan.deletes))
+	return plan, nil
+}
 
-	// Finally, add the records from the input
-	for _, record := range records {
-		created, err := p.createDomainRecord(ctx, zone, domainID, record)
+// recordJournal remembers the changes applyRecordPlan has made so far, so that they can be undone.
+type recordJournal struct {
+	// created are the records that did not exist before.
+	created []linodego.DomainRecord
+	// updated are the original contents of records that were overwritten in place.
+	updated []linodego.DomainRecord
+	// deleted are the original contents of records that were removed.
+	deleted []linodego.DomainRecord
+}
+
+// applyRecordPlan performs the changes in plan and returns the resulting records in the same order as desired.
+// Updates, creations and deletions are done in that order, each with up to Provider.Workers requests at a time. The
+// first failure stops the remaining changes, and the failures of the requests that were in flight at the time are
+// returned together. The returned journal holds every change that was made, even when an error is returned.
+func (p *Provider) applyRecordPlan(ctx context.Context, zone string, domainID int, desired []libdns.Record, plan recordPlan) ([]libdns.Record, recordJournal, error) {
+	p.logger.Debug("Enter applyRecordPlan", "zone", zone, "domainID", domainID, "lenDesired", len(desired))
+	journal := recordJournal{}
+	results := make([]libdns.Record, len(desired))
+	for _, unchanged := range plan.unchanged {
+		librec, err := convertToLibdns(p.logger, &unchanged.existing)
 		if err != nil {
-			return nil, fmt.Errorf("could not create domain record: %w", err)
+			return nil, journal, fmt.Errorf("could not convert record to libdns struct: %w", err)
 		}
-		setRecords = append(setRecords, created)
+		results[unchanged.index] = librec
 	}
 
-	slog.Debug("Exit createOrUpdateDomainRecords", "zone", zone, "domainID", domainID, "lenSetRecords", len(setRecords))
-	return setRecords, nil
+	// Each request only writes its own entries of updated and results; the journal is filled in afterwards, in plan order
+	updated := make([]bool, len(plan.updates))
+	errs := p.runConcurrently(len(plan.updates), true, func(i int) error {
+		update := plan.updates[i]
+		record, err := p.updateLinodeRecord(ctx, zone, domainID, update.existing.ID, desired[update.index])
+		if err != nil {
+			return fmt.Errorf("could not update domain record %d: %w", update.existing.ID, err)
+		}
+		updated[i] = true
+		librec, err := convertToLibdns(p.logger, record)
+		if err != nil {
+			return fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		results[update.index] = librec
+		return nil
+	})
+	for i, update := range plan.updates {
+		if updated[i] {
+			journal.updated = append(journal.updated, update.existing)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, journal, err
+	}
+
+	created := make([]*linodego.DomainRecord, len(plan.creates))
+	errs = p.runConcurrently(len(plan.creates), true, func(i int) error {
+		record, err := p.createLinodeRecord(ctx, zone, domainID, desired[plan.creates[i]])
+		if err != nil {
+			return fmt.Errorf("could not create domain record: %w", err)
+		}
+		created[i] = record
+		librec, err := convertToLibdns(p.logger, record)
+		if err != nil {
+			return fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		results[plan.creates[i]] = librec
+		return nil
+	})
+	for _, record := range created {
+		if record != nil {
+			journal.created = append(journal.created, *record)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, journal, err
+	}
+
+	deleted := make([]bool, len(plan.deletes))
+	errs = p.runConcurrently(len(plan.deletes), true, func(i int) error {
+		record := plan.deletes[i]
+		if err := p.apiDeleteDomainRecord(ctx, domainID, record.ID); err != nil {
+			return fmt.Errorf("could not delete domain record %d: %w", record.ID, classifyAPIError(err))
+		}
+		deleted[i] = true
+		return nil
+	})
+	for i, record := range plan.deletes {
+		if deleted[i] {
+			journal.deleted = append(journal.deleted, record)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, journal, err
+	}
+	p.logger.Debug("Exit applyRecordPlan", "zone", zone, "domainID", domainID, "lenResults", len(results))
+	return results, journal, nil
+}
+
+// applyRecordPlanOrRollBack is applyRecordPlan, except that if Transactional is set and a change fails, the changes
+// already made are rolled back and the returned error describes both the failure and the outcome of the rollback.
+func (p *Provider) applyRecordPlanOrRollBack(ctx context.Context, zone string, domainID int, desired []libdns.Record, plan recordPlan) ([]libdns.Record, error) {
+	results, journal, err := p.applyRecordPlan(ctx, zone, domainID, desired, plan)
+	if err != nil {
+		if !p.Transactional {
+			return nil, err
+		}
+		// Roll back even if ctx is what caused the failure
+		if rollbackErr := p.rollbackRecordChanges(context.WithoutCancel(ctx), domainID, journal); rollbackErr != nil {
+			return nil, fmt.Errorf("%w; rollback failed, the zone may be left partially updated: %w", err, rollbackErr)
+		}
+		return nil, fmt.Errorf("%w; all changes were rolled back", err)
+	}
+	return results, nil
+}
+
+// rollbackRecordChanges undoes the changes in journal. Deleted records are recreated first and created records are
+// removed last, so that names keep resolving while the rollback is in progress. Recreated records get new Linode
+// record IDs. It attempts every step and returns all errors encountered.
+func (p *Provider) rollbackRecordChanges(ctx context.Context, domainID int, journal recordJournal) error {
+	p.logger.Debug("Enter rollbackRecordChanges", "domainID", domainID, "lenCreated", len(journal.created),
+		"lenUpdated", len(journal.updated), "lenDeleted", len(journal.deleted))
+	var errs []error
+	for _, record := range journal.deleted {
+		if _, err := p.apiCreateDomainRecord(ctx, domainID, createOptionsFromDomainRecord(record)); err != nil {
+			errs = append(errs, fmt.Errorf("could not recreate domain record %d: %w", record.ID, classifyAPIError(err)))
+		}
+	}
+	for _, record := range journal.updated {
+		updateOpts := linodego.DomainRecordUpdateOptions(createOptionsFromDomainRecord(record))
+		if _, err := p.apiUpdateDomainRecord(ctx, domainID, record.ID, updateOpts); err != nil {
+			errs = append(errs, fmt.Errorf("could not restore domain record %d: %w", record.ID, classifyAPIError(err)))
+		}
+	}
+	for _, record := range journal.created {
+		if err := p.apiDeleteDomainRecord(ctx, domainID, record.ID); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete domain record %d: %w", record.ID, classifyAPIError(err)))
+		}
+	}
+	err := errors.Join(errs...)
+	p.logger.Debug("Exit rollbackRecordChanges", "domainID", domainID, "err", err)
+	return err
 }
 
 func (p *Provider) createDomainRecord(ctx context.Context, zone string, domainID int, record libdns.Record) (libdns.Record, error) {
+	addedLinodeRecord, err := p.createLinodeRecord(ctx, zone, domainID, record)
+	if err != nil {
+		return nil, err
+	}
+	return convertToLibdns(p.logger, addedLinodeRecord)
+}
+
+func (p *Provider) createLinodeRecord(ctx context.Context, zone string, domainID int, record libdns.Record) (*linodego.DomainRecord, error) {
+	rr := record.RR()
+	p.logger.Debug("Enter createLinodeRecord", "zone", zone, "domainID", domainID, "name", rr.Name, "type", rr.Type)
+	createOpts, err := convertToDomainRecord(p.logger, record, zone)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert record to linodego struct: %w", err)
+	}
+	addedLinodeRecord, err := p.apiCreateDomainRecord(ctx, domainID, createOpts)
+	if err != nil {
+		return nil, fmt.Errorf("could not create domain record: %w", classifyAPIError(err))
+	}
+	p.logger.Debug("Exit createLinodeRecord", "zone", zone, "domainID", domainID, "name", addedLinodeRecord.Name, "type", addedLinodeRecord.Type, "recordID", addedLinodeRecord.ID)
+	return addedLinodeRecord, nil
+}
+
+// updateLinodeRecord overwrites the existing record recordID with record, keeping its Linode record ID.
+func (p *Provider) updateLinodeRecord(ctx context.Context, zone string, domainID int, recordID int, record libdns.Record) (*linodego.DomainRecord, error) {
 	rr := record.RR()
-	slog.Debug("Enter createDomainRecord", "zone", zone, "domainID", domainID, "name", rr.Name, "type", rr.Type)
-	createOpts, err := convertToDomainRecord(record, zone)
+	p.logger.Debug("Enter updateLinodeRecord", "zone", zone, "domainID", domainID, "recordID", recordID, "name", rr.Name, "type", rr.Type)
+	createOpts, err := convertToDomainRecord(p.logger, record, zone)
 	if err != nil {
 		return nil, fmt.Errorf("could not convert record to linodego struct: %w", err)
 	}
-	addedLinodeRecord, err := p.client.CreateDomainRecord(ctx, domainID, createOpts)
+	// The create and update options share the same fields
+	updatedLinodeRecord, err := p.apiUpdateDomainRecord(ctx, domainID, recordID, linodego.DomainRecordUpdateOptions(createOpts))
 	if err != nil {
-		return nil, fmt.Errorf("could not create domain record: %w", err)
+		return nil, fmt.Errorf("could not update domain record: %w", classifyAPIError(err))
 	}
-	librec, err := convertToLibdns(addedLinodeRecord)
-	slog.Debug("Exit createDomainRecord", "zone", zone, "domainID", domainID, "name", addedLinodeRecord.Name, "type", addedLinodeRecord.Type, "err", err)
-	return librec, err
+	p.logger.Debug("Exit updateLinodeRecord", "zone", zone, "domainID", domainID, "recordID", recordID, "name", updatedLinodeRecord.Name, "type", updatedLinodeRecord.Type)
+	return updatedLinodeRecord, nil
 }
 
 // deleteDomainRecords deletes each record from the zone. It returns the records that were deleted.
@@ -167,40 +579,41 @@ func (p *Provider) createDomainRecord(ctx context.Context, zone string, domainID
 // Note: this does not apply to the Name field.
 // Since there are wildcards for Type, TTL, and Value, it can delete multiple records for each input record.
 func (p *Provider) deleteDomainRecords(ctx context.Context, domainID int, records []libdns.Record) ([]libdns.Record, error) {
-	slog.Debug("Enter deleteDomainRecords", "domainID", domainID, "lenRecords", len(records))
-	// Future improvement?: It should be possible to use the linodego.ListOptions to filter by Name, Type, TTL, and Value.
-	// Though this would change the number of API calls from one (list all) to N, where N is the number of records to delete.
-	// For now, we just list all records and delete them one by one.
-	linodeRecords, err := p.client.ListDomainRecords(ctx, domainID, nil)
+	p.logger.Debug("Enter deleteDomainRecords", "domainID", domainID, "lenRecords", len(records))
+	for _, record := range records {
+		if record.RR().Name == "" {
+			return nil, fmt.Errorf("record name is required")
+		}
+	}
+	linodeRecords, err := p.listRecordCandidates(ctx, domainID, records)
 	if err != nil {
-		return nil, fmt.Errorf("could not list domain records: %w", err)
+		return nil, err
 	}
-	deletedLinodeRecords := make([]bool, len(linodeRecords))
+	matchedLinodeRecords := make([]bool, len(linodeRecords))
 
-	deleted := make([]libdns.Record, 0)
+	// Work out which records to delete first, so that the deletions can be sent concurrently
+	type match struct {
+		id      int
+		librec  libdns.Record
+		deleted bool
+	}
+	matches := make([]match, 0)
 	for _, record := range records {
 		rr := record.RR()
-		if rr.Name == "" {
-			return nil, fmt.Errorf("record name is required")
-		}
 
 		for lrecI, lrec := range linodeRecords {
-			if deletedLinodeRecords[lrecI] {
-				continue // Already deleted
+			if matchedLinodeRecords[lrecI] {
+				continue // Already matched by an earlier input record
 			}
 			// Convert Linode record to libdns record for consistent comparison logic
-			librec, err := convertToLibdns(&lrec)
+			librec, err := convertToLibdns(p.logger, &lrec)
 			if err != nil {
-				// Skip records that cannot be represented in libdns (e.g., PTR)
-				if lrec.Type == linodego.RecordTypePTR {
-					continue
-				}
-				return deleted, fmt.Errorf("could not convert record to libdns struct: %w", err)
+				return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
 			}
 			lrr := librec.RR()
 
-			// Name must always match exactly
-			if lrr.Name != rr.Name {
+			// Name must always match, apart from case and IDN form
+			if asciiName(lrr.Name) != asciiName(rr.Name) {
 				continue
 			}
 			// Type/TTL/Data support wildcards when zero values are provided in input
@@ -213,22 +626,110 @@ func (p *Provider) deleteDomainRecords(ctx context.Context, domainID int, record
 			if rr.Data != "" && lrr.Data != rr.Data {
 				continue
 			}
+			matchedLinodeRecords[lrecI] = true
+			matches = append(matches, match{id: lrec.ID, librec: librec})
+		}
+	}
 
-			// Delete the matching record
-			if err := p.client.DeleteDomainRecord(ctx, domainID, lrec.ID); err != nil {
-				return deleted, fmt.Errorf("could not delete domain record %d: %w", lrec.ID, err)
-			}
-			deletedLinodeRecords[lrecI] = true
-			deleted = append(deleted, librec)
+	// Delete the matching records; the first failure stops the deletions that have not started yet
+	errs := p.runConcurrently(len(matches), true, func(i int) error {
+		if err := p.apiDeleteDomainRecord(ctx, domainID, matches[i].id); err != nil {
+			return fmt.Errorf("could not delete domain record %d: %w", matches[i].id, classifyAPIError(err))
 		}
+		matches[i].deleted = true
+		return nil
+	})
+	deleted := make([]libdns.Record, 0, len(matches))
+	for _, m := range matches {
+		if m.deleted {
+			deleted = append(deleted, m.librec)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return deleted, err
 	}
 
-	slog.Debug("Exit deleteDomainRecords", "domainID", domainID, "lenDeleted", len(deleted))
+	p.logger.Debug("Exit deleteDomainRecords", "domainID", domainID, "lenDeleted", len(deleted))
 	return deleted, nil
 }
 
-func convertToLibdns(linodeRecord *linodego.DomainRecord) (libdns.Record, error) {
-	slog.Debug("Enter convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name)
+// listRecordCandidates returns the records of the domain that may match the input records, for deleteDomainRecords
+// to match exactly. When there are at most FilteredLookupThreshold input records, each is looked up with a filtered
+// request, which is much cheaper than listing a large zone. Otherwise, or when an input record cannot be expressed as
+// a filter, the whole zone is listed.
+func (p *Provider) listRecordCandidates(ctx context.Context, domainID int, records []libdns.Record) ([]linodego.DomainRecord, error) {
+	threshold := p.FilteredLookupThreshold
+	if threshold == 0 {
+		threshold = DefaultFilteredLookupThreshold
+	}
+	filters := make([]string, 0, len(records))
+	if len(records) <= threshold {
+		for _, record := range records {
+			filter, ok := recordLookupFilter(record.RR())
+			if !ok {
+				filters = nil
+				break
+			}
+			filters = append(filters, filter)
+		}
+	}
+	if len(filters) == 0 {
+		p.logger.Debug("listing all records", "domainID", domainID, "lenRecords", len(records), "threshold", threshold)
+		linodeRecords, err := p.listAllDomainRecords(ctx, domainID, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not list domain records: %w", err)
+		}
+		return linodeRecords, nil
+	}
+
+	p.logger.Debug("looking up records with filters", "domainID", domainID, "lenFilters", len(filters))
+	candidates := make([]linodego.DomainRecord, 0)
+	seen := make(map[int]struct{})
+	queried := make(map[string]struct{})
+	for _, filter := range filters {
+		if _, ok := queried[filter]; ok {
+			continue
+		}
+		queried[filter] = struct{}{}
+		linodeRecords, err := p.listAllDomainRecords(ctx, domainID, filter)
+		if err != nil {
+			return nil, fmt.Errorf("could not list domain records: %w", err)
+		}
+		for _, linodeRecord := range linodeRecords {
+			if _, ok := seen[linodeRecord.ID]; ok {
+				continue
+			}
+			seen[linodeRecord.ID] = struct{}{}
+			candidates = append(candidates, linodeRecord)
+		}
+	}
+	return candidates, nil
+}
+
+// recordLookupFilter returns an X-Filter selecting the Linode records that may match rr, or false if there is none.
+// The name is only filtered on when it is stored by Linode as-is: not for the apex, which Linode stores as "", and not
+// for SRV records, whose libdns name differs from the Linode one. TTL and data are always matched in memory.
+func recordLookupFilter(rr libdns.RR) (string, bool) {
+	f := linodego.Filter{}
+	isSRV := rr.Type == string(linodego.RecordTypeSRV) || (rr.Type == "" && strings.HasPrefix(rr.Name, "_"))
+	if rr.Name != "@" && !isSRV {
+		f.AddField(linodego.Eq, "name", asciiName(rr.Name))
+	}
+	if rr.Type != "" {
+		f.AddField(linodego.Eq, "type", rr.Type)
+	}
+	if len(f.Children) == 0 {
+		return "", false
+	}
+	filter, err := f.MarshalJSON()
+	if err != nil {
+		return "", false
+	}
+	return string(filter), true
+}
+
+func convertToLibdns(logger *slog.Logger, linodeRecord *linodego.DomainRecord) (libdns.Record, error) {
+	logger.Debug("Enter convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name)
 	switch linodeRecord.Type {
 	case linodego.RecordTypeA:
 		fallthrough
@@ -241,14 +742,14 @@ func convertToLibdns(linodeRecord *linodego.DomainRecord) (libdns.Record, error)
 			return nil, fmt.Errorf("could not parse target as IP: %w", err)
 		}
 		record.IP = ip
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "Address")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "Address")
 		return record, nil
 	case linodego.RecordTypeNS:
 		record := libdns.NS{}
 		record.Name = libdnsWantsAtSym(linodeRecord.Name)
 		record.TTL = time.Duration(linodeRecord.TTLSec) * time.Second
 		record.Target = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "NS")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "NS")
 		return record, nil
 	case linodego.RecordTypeMX:
 		record := libdns.MX{}
@@ -256,21 +757,21 @@ func convertToLibdns(linodeRecord *linodego.DomainRecord) (libdns.Record, error)
 		record.TTL = time.Duration(linodeRecord.TTLSec) * time.Second
 		record.Preference = uint16(linodeRecord.Priority)
 		record.Target = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "MX")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "MX")
 		return record, nil
 	case linodego.RecordTypeCNAME:
 		record := libdns.CNAME{}
 		record.Name = libdnsWantsAtSym(linodeRecord.Name)
 		record.TTL = time.Duration(linodeRecord.TTLSec) * time.Second
 		record.Target = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "CNAME")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "CNAME")
 		return record, nil
 	case linodego.RecordTypeTXT:
 		record := libdns.TXT{}
 		record.Name = libdnsWantsAtSym(linodeRecord.Name)
 		record.TTL = time.Duration(linodeRecord.TTLSec) * time.Second
 		record.Text = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "TXT")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "TXT")
 		return record, nil
 	case linodego.RecordTypeSRV:
 		record := libdns.SRV{}
@@ -284,18 +785,19 @@ func convertToLibdns(linodeRecord *linodego.DomainRecord) (libdns.Record, error)
 			transport = *linodeRecord.Protocol
 		}
 		record.Transport = transport
-		record.Name = libdnsWantsAtSym(linodeRecord.Name)
+		// Linode names SRV records _service._protocol[.name], while libdns only wants the name below them
+		name, ok := strings.CutPrefix(linodeRecord.Name, "_"+service+"._"+transport)
+		if !ok {
+			name = linodeRecord.Name
+		}
+		record.Name = libdnsWantsAtSym(strings.TrimPrefix(name, "."))
 		record.TTL = time.Duration(linodeRecord.TTLSec) * time.Second
 		record.Priority = uint16(linodeRecord.Priority)
 		record.Weight = uint16(linodeRecord.Weight)
 		record.Port = uint16(linodeRecord.Port)
 		record.Target = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "SRV")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "SRV")
 		return record, nil
-	case linodego.RecordTypePTR:
-		// Can't be represented in libdns
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "PTR")
-		return nil, fmt.Errorf("libdns does not support PTR records")
 	case linodego.RecordTypeCAA:
 		record := libdns.CAA{}
 		record.Name = libdnsWantsAtSym(linodeRecord.Name)
@@ -308,20 +810,36 @@ func convertToLibdns(linodeRecord *linodego.DomainRecord) (libdns.Record, error)
 		}
 		record.Tag = *linodeRecord.Tag
 		record.Value = linodeRecord.Target
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "CAA")
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "CAA")
 		return record, nil
 	default:
-		slog.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "Unknown")
-		return nil, fmt.Errorf("unknown record type: %v", linodeRecord.Type)
+		// libdns has no type for PTR or any type Linode may add in the future, so these are returned as generic
+		// resource records with the target as their data.
+		record := libdns.RR{
+			Name: libdnsWantsAtSym(linodeRecord.Name),
+			TTL:  time.Duration(linodeRecord.TTLSec) * time.Second,
+			Type: string(linodeRecord.Type),
+			Data: linodeRecord.Target,
+		}
+		logger.Debug("Exit convertToLibdns", "type", linodeRecord.Type, "name", linodeRecord.Name, "as", "RR")
+		return record, nil
 	}
 }
 
-func convertToDomainRecord(record libdns.Record, zone string) (linodego.DomainRecordCreateOptions, error) {
+func convertToDomainRecord(logger *slog.Logger, record libdns.Record, zone string) (linodego.DomainRecordCreateOptions, error) {
 	rr := record.RR()
-	slog.Debug("Enter convertToDomainRecord", "zone", zone, "name", rr.Name, "type", rr.Type)
+	logger.Debug("Enter convertToDomainRecord", "zone", zone, "name", rr.Name, "type", rr.Type)
+	if genericRecord, ok := record.(libdns.RR); ok {
+		// Parse generic records into their specific type where libdns has one, so that e.g. the MX preference is set
+		parsed, err := genericRecord.Parse()
+		if err != nil {
+			return linodego.DomainRecordCreateOptions{}, fmt.Errorf("could not parse %s record %q: %w", rr.Type, rr.Name, err)
+		}
+		record = parsed
+	}
 	domainRecord := linodego.DomainRecordCreateOptions{
 		Type:   linodego.DomainRecordType(rr.Type),
-		Name:   linodeDoesntWantAtSym(libdns.RelativeName(rr.Name, zone)),
+		Name:   linodeDoesntWantAtSym(libdns.RelativeName(asciiName(rr.Name), normalizeZone(zone))),
 		Target: rr.Data, // This is often sufficient, but for some record types we have to fix this up later
 		TTLSec: int(rr.TTL.Seconds()),
 	}
@@ -361,11 +879,45 @@ func convertToDomainRecord(record libdns.Record, zone string) (linodego.DomainRe
 		return linodego.DomainRecordCreateOptions{}, fmt.Errorf("linode does not support ServiceBinding records (%+v): %w", record, ErrUnsupportedType)
 	case libdns.TXT:
 		// All necessary fields are set
+	case libdns.RR:
+		// Only types libdns has no specific struct for are left
+		if domainRecord.Type != linodego.RecordTypePTR {
+			return linodego.DomainRecordCreateOptions{}, fmt.Errorf("linode does not support %s records (%+v): %w", rr.Type, record, ErrUnsupportedType)
+		}
 	}
-	slog.Debug("Exit convertToDomainRecord", "zone", zone, "name", rr.Name, "type", rr.Type, "options", domainRecord)
+	logger.Debug("Exit convertToDomainRecord", "zone", zone, "name", rr.Name, "type", rr.Type, "options", domainRecord)
 	return domainRecord, nil
 }
 
+// createOptionsFromDomainRecord returns the options that recreate an existing Linode record. Only the fields that
+// apply to the record type are set.
+func createOptionsFromDomainRecord(record linodego.DomainRecord) linodego.DomainRecordCreateOptions {
+	createOpts := linodego.DomainRecordCreateOptions{
+		Type:   record.Type,
+		Name:   record.Name,
+		Target: record.Target,
+		TTLSec: record.TTLSec,
+	}
+	switch record.Type {
+	case linodego.RecordTypeMX:
+		priority := record.Priority
+		createOpts.Priority = &priority
+	case linodego.RecordTypeSRV:
+		createOpts.Name = "" // Name is not applicable for SRV records
+		priority := record.Priority
+		createOpts.Priority = &priority
+		weight := record.Weight
+		createOpts.Weight = &weight
+		port := record.Port
+		createOpts.Port = &port
+		createOpts.Service = record.Service
+		createOpts.Protocol = record.Protocol
+	case linodego.RecordTypeCAA:
+		createOpts.Tag = record.Tag
+	}
+	return createOpts
+}
+
 func libdnsWantsAtSym(name string) string {
 	if name == "" {
 		return "@"
@@ -379,3 +931,8 @@ func linodeDoesntWantAtSym(name string) string {
 	}
 	return name
 }
+
+// domainName returns the Linode domain name of the zone, which is in ASCII and has no trailing dot.
+func domainName(zone string) string {
+	return normalizeZone(zone)
+}
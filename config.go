@@ -0,0 +1,126 @@
+package linode
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/linode/linodego"
+)
+
+// Environment variables the Provider falls back to when the corresponding fields are empty.
+const (
+	// EnvAPIToken holds the Linode API token, as for the Linode CLI and Terraform provider.
+	EnvAPIToken = "LINODE_TOKEN"
+	// EnvDNSAPIToken holds the Linode API token; it is only read when EnvAPIToken is not set.
+	EnvDNSAPIToken = "LINODE_DNS_PAT"
+	// EnvAPIURL holds the Linode API URL.
+	EnvAPIURL = "LINODE_URL"
+	// EnvAPIVersion holds the Linode API version.
+	EnvAPIVersion = "LINODE_API_VERSION"
+	// EnvConfigPath holds the path of the Linode config file, by default ~/.config/linode or ~/.config/linode-cli.
+	EnvConfigPath = "LINODE_CONFIG"
+	// EnvConfigProfile holds the profile to use from the Linode config file.
+	EnvConfigProfile = "LINODE_PROFILE"
+)
+
+// NewProviderFromEnv returns a Provider configured from the environment variables above, and checks that it has
+// credentials to use. The returned Provider can be customized further before it is first used.
+func NewProviderFromEnv() (*Provider, error) {
+	p := &Provider{
+		APIToken:      cmp.Or(os.Getenv(EnvAPIToken), os.Getenv(EnvDNSAPIToken)),
+		APIURL:        os.Getenv(EnvAPIURL),
+		APIVersion:    os.Getenv(EnvAPIVersion),
+		ConfigProfile: os.Getenv(EnvConfigProfile),
+	}
+	creds, err := p.resolveCredentials()
+	if err != nil {
+		return nil, err
+	}
+	// Load the config file profile, if any, into a throwaway client so that a broken profile is reported now
+	client := linodego.NewClient(http.DefaultClient)
+	if err := creds.configure(&client); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// credentials are the resolved API settings of a Provider.
+type credentials struct {
+	token   string
+	url     string
+	version string
+	// configPath and profile select a profile of a Linode config file. They are only set when no token was found
+	// elsewhere, in which case the profile provides the token, and the URL and version unless they are set.
+	configPath string
+	profile    string
+}
+
+// resolveCredentials works out the API settings of the provider. The token is taken from the first of APIToken,
+// APITokenFile, EnvAPIToken, EnvDNSAPIToken and a Linode config file profile that is set. The URL and version are
+// taken from the fields, or else from the environment.
+func (p *Provider) resolveCredentials() (credentials, error) {
+	creds := credentials{
+		url:     cmp.Or(p.APIURL, os.Getenv(EnvAPIURL)),
+		version: cmp.Or(p.APIVersion, os.Getenv(EnvAPIVersion)),
+	}
+	switch {
+	case p.APIToken != "":
+		creds.token = p.APIToken
+	case p.APITokenFile != "":
+		token, err := os.ReadFile(p.APITokenFile)
+		if err != nil {
+			return credentials{}, fmt.Errorf("%w: could not read APITokenFile: %w", ErrNoCredentials, err)
+		}
+		creds.token = strings.TrimSpace(string(token))
+		if creds.token == "" {
+			return credentials{}, fmt.Errorf("%w: APITokenFile %s is empty", ErrNoCredentials, p.APITokenFile)
+		}
+	default:
+		creds.token = cmp.Or(os.Getenv(EnvAPIToken), os.Getenv(EnvDNSAPIToken))
+	}
+	if creds.token != "" {
+		return creds, nil
+	}
+
+	creds.configPath = os.Getenv(EnvConfigPath)
+	if creds.configPath == "" {
+		for _, format := range linodego.DefaultConfigPaths {
+			path, err := linodego.FormatConfigPath(format)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(path); err == nil {
+				creds.configPath = path
+				break
+			}
+		}
+	}
+	if creds.configPath == "" {
+		return credentials{}, fmt.Errorf("%w: set APIToken or APITokenFile, the %s or %s environment variable, or create a Linode config file",
+			ErrNoCredentials, EnvAPIToken, EnvDNSAPIToken)
+	}
+	creds.profile = cmp.Or(p.ConfigProfile, os.Getenv(EnvConfigProfile), linodego.DefaultConfigProfile)
+	return creds, nil
+}
+
+// configure applies the settings to a linodego client.
+func (creds credentials) configure(client *linodego.Client) error {
+	if creds.token != "" {
+		client.SetToken(creds.token)
+	} else {
+		opts := &linodego.LoadConfigOptions{Path: creds.configPath, Profile: creds.profile}
+		if err := client.LoadConfig(opts); err != nil {
+			return fmt.Errorf("%w: could not load profile %q from %s: %w", ErrNoCredentials, creds.profile, creds.configPath, err)
+		}
+	}
+	if creds.url != "" {
+		client.SetBaseURL(creds.url)
+	}
+	if creds.version != "" {
+		client.SetAPIVersion(creds.version)
+	}
+	return nil
+}
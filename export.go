@@ -0,0 +1,185 @@
+package linode
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+)
+
+// Linode's defaults for the SOA timers and TTL of a domain, which apply when they are set to 0.
+const (
+	linodeDefaultRefresh = 14400
+	linodeDefaultRetry   = 14400
+	linodeDefaultExpire  = 1209600
+	linodeDefaultTTL     = 86400
+)
+
+// linodeNameServers serve every master domain. Linode adds their NS records to the zone itself; they are not listed
+// among the domain records.
+var linodeNameServers = []string{"ns1.linode.com.", "ns2.linode.com.", "ns3.linode.com.", "ns4.linode.com.", "ns5.linode.com."}
+
+// ExportZone writes the zone as an RFC 1035 master file: $ORIGIN and $TTL directives, the SOA record and Linode's NS
+// records for master zones, and every record in the zone. Names are written in their ASCII form, and records whose
+// TTL is the zone default are written without one.
+func (p *Provider) ExportZone(ctx context.Context, zone string, w io.Writer) error {
+	if err := p.init(ctx); err != nil {
+		return err
+	}
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter ExportZone", "zone", zone)
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	domain, err := p.apiGetDomain(ctx, domainID)
+	if err != nil {
+		err = classifyAPIError(err)
+		p.forgetZoneIfGone(zone, err)
+		return fmt.Errorf("could not get domain %d: %w", domainID, err)
+	}
+	records, err := p.listDomainRecords(ctx, domainID)
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return fmt.Errorf("error listing domain records: %w", err)
+	}
+	if err := writeZoneFile(w, *domain, records, time.Now()); err != nil {
+		return fmt.Errorf("could not write zone file: %w", err)
+	}
+	p.logger.Debug("Exit ExportZone", "zone", zone, "domainID", domainID, "lenRecords", len(records))
+	return nil
+}
+
+// writeZoneFile renders the domain and its records as a master file. The SOA serial is derived from now.
+func writeZoneFile(w io.Writer, domain linodego.Domain, records []libdns.Record, now time.Time) error {
+	origin := normalizeZone(domain.Domain) + "."
+	ttl := cmp.Or(domain.TTLSec, linodeDefaultTTL)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "; %s exported from Linode domain %d on %s\n", origin, domain.ID, now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tw, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(tw, "$TTL %d\n", ttl)
+	if domain.Type == linodego.DomainTypeSlave {
+		fmt.Fprintf(tw, "; slave zone transferred from %s\n", strings.Join(domain.MasterIPs, ", "))
+	} else {
+		fmt.Fprintf(tw, "@\t\tIN\tSOA\t%s %s (%s00 %d %d %d %d)\n", linodeNameServers[0], soaMailbox(domain.SOAEmail),
+			now.UTC().Format("20060102"), cmp.Or(domain.RefreshSec, linodeDefaultRefresh),
+			cmp.Or(domain.RetrySec, linodeDefaultRetry), cmp.Or(domain.ExpireSec, linodeDefaultExpire), ttl)
+		for _, ns := range linodeNameServers {
+			fmt.Fprintf(tw, "@\t\tIN\tNS\t%s\n", ns)
+		}
+	}
+
+	lines := make([]zoneFileLine, 0, len(records))
+	for _, record := range records {
+		lines = append(lines, zoneFileLineOf(record))
+	}
+	// Group the records by name, apex first, then by type, keeping Linode's order within an RRset
+	slices.SortStableFunc(lines, func(a, b zoneFileLine) int {
+		return cmp.Or(cmp.Compare(apexRank(a.name), apexRank(b.name)), strings.Compare(a.name, b.name),
+			strings.Compare(a.typ, b.typ))
+	})
+	for _, line := range lines {
+		ttlField := ""
+		if line.ttl != 0 && line.ttl != ttl {
+			ttlField = fmt.Sprint(line.ttl)
+		}
+		fmt.Fprintf(tw, "%s\t%s\tIN\t%s\t%s\n", line.name, ttlField, line.typ, line.data)
+	}
+	return tw.Flush()
+}
+
+// zoneFileLine is a record in master file presentation format.
+type zoneFileLine struct {
+	name string
+	ttl  int
+	typ  string
+	data string
+}
+
+func zoneFileLineOf(record libdns.Record) zoneFileLine {
+	rr := record.RR()
+	line := zoneFileLine{name: asciiName(rr.Name), ttl: int(rr.TTL.Seconds()), typ: rr.Type, data: rr.Data}
+	switch rec := record.(type) {
+	case libdns.CNAME:
+		line.data = absoluteTarget(rec.Target)
+	case libdns.NS:
+		line.data = absoluteTarget(rec.Target)
+	case libdns.MX:
+		line.data = fmt.Sprintf("%d %s", rec.Preference, absoluteTarget(rec.Target))
+	case libdns.SRV:
+		line.data = fmt.Sprintf("%d %d %d %s", rec.Priority, rec.Weight, rec.Port, absoluteTarget(rec.Target))
+	case libdns.TXT:
+		line.data = quoteCharacterStrings(rec.Text)
+	case libdns.CAA:
+		line.data = fmt.Sprintf("%d %s %s", rec.Flags, rec.Tag, quoteString(rec.Value))
+	case libdns.RR:
+		if rec.Type == string(linodego.RecordTypePTR) {
+			line.data = absoluteTarget(rec.Data)
+		}
+	}
+	return line
+}
+
+// apexRank sorts the apex before the other names.
+func apexRank(name string) int {
+	if name == "@" {
+		return 0
+	}
+	return 1
+}
+
+// absoluteTarget returns a host name stored by Linode, which has no trailing dot, as an absolute name.
+func absoluteTarget(target string) string {
+	if target == "" || strings.HasSuffix(target, ".") {
+		return target
+	}
+	return asciiName(target) + "."
+}
+
+// soaMailbox returns an email address as the RNAME of an SOA record, escaping the dots of the local part.
+func soaMailbox(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return absoluteTarget(email)
+	}
+	return strings.ReplaceAll(local, ".", `\.`) + "." + absoluteTarget(domain)
+}
+
+// quoteCharacterStrings renders text as RFC 1035 character-strings of at most 255 bytes each, as TXT records need.
+func quoteCharacterStrings(text string) string {
+	chunks := make([]string, 0, len(text)/255+1)
+	for len(text) > 255 {
+		chunks = append(chunks, quoteString(text[:255]))
+		text = text[255:]
+	}
+	chunks = append(chunks, quoteString(text))
+	return strings.Join(chunks, " ")
+}
+
+// quoteString renders s in double quotes, escaping quotes and backslashes, and bytes other than printable ASCII as
+// \DDD.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, `\%03d`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
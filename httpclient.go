@@ -0,0 +1,73 @@
+package linode
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/linode/linodego"
+)
+
+// DefaultTimeout is used when Provider.Timeout is zero and Provider.HTTPClient has no timeout of its own.
+const DefaultTimeout = 30 * time.Second
+
+// modulePath is the module path of this package, used to find its version in the build info.
+const modulePath = "github.com/HugoKlepsch/libdns-linode"
+
+// httpClient returns the HTTP client the provider sends its requests with. It is a copy of HTTPClient, if set, so
+// that the caller's client is never modified.
+func (p *Provider) httpClient() *http.Client {
+	client := &http.Client{}
+	if p.HTTPClient != nil {
+		*client = *p.HTTPClient
+	}
+	switch {
+	case p.Timeout > 0:
+		client.Timeout = p.Timeout
+	case p.Timeout < 0:
+		client.Timeout = 0
+	case client.Timeout == 0:
+		client.Timeout = DefaultTimeout
+	}
+	if p.WrapTransport != nil {
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		client.Transport = p.WrapTransport(transport)
+	}
+	return client
+}
+
+// userAgent returns the User-Agent header the provider sends.
+func (p *Provider) userAgent() string {
+	if p.UserAgent != "" {
+		return p.UserAgent
+	}
+	return "libdns-linode/" + moduleVersion() + " " + linodego.DefaultUserAgent
+}
+
+// moduleVersion returns the version of this module in the running binary, or "dev" if it is unknown.
+var moduleVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+			if dep.Replace != nil {
+				version = dep.Replace.Version
+			}
+		}
+	}
+	if version == "" || version == "(devel)" {
+		return "dev"
+	}
+	return version
+})
@@ -0,0 +1,64 @@
+package linode
+
+import (
+	"strings"
+
+	"github.com/libdns/libdns"
+	"golang.org/x/net/idna"
+)
+
+// NameForm is the form in which the provider returns zone and record names.
+type NameForm string
+
+const (
+	// NameFormASCII returns internationalized names in their ASCII ("xn--") form, as Linode stores them.
+	NameFormASCII NameForm = "ascii"
+	// NameFormUnicode returns internationalized names in their Unicode form.
+	NameFormUnicode NameForm = "unicode"
+)
+
+// idnaProfile converts names between their Unicode and ASCII forms. Unlike idna.Lookup, it allows the underscores,
+// wildcards and "@" found in record names.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
+// asciiName returns the form of a zone or record name used to talk to Linode and to compare names: internationalized
+// labels in their ASCII form and everything in lower case. A trailing dot is kept.
+func asciiName(name string) string {
+	ascii, err := idnaProfile.ToASCII(name)
+	if err != nil {
+		return strings.ToLower(name)
+	}
+	return ascii
+}
+
+// presentName returns a name from Linode in the form selected by NameForm.
+func (p *Provider) presentName(name string) string {
+	if p.NameForm != NameFormUnicode {
+		return name
+	}
+	unicode, err := idnaProfile.ToUnicode(name)
+	if err != nil {
+		return name
+	}
+	return unicode
+}
+
+// presentRecords returns records from Linode with their names in the form selected by NameForm.
+func (p *Provider) presentRecords(records []libdns.Record) []libdns.Record {
+	if p.NameForm != NameFormUnicode {
+		return records
+	}
+	presented := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		presented = append(presented, p.presentRecord(record))
+	}
+	return presented
+}
+
+func (p *Provider) presentRecord(record libdns.Record) libdns.Record {
+	name := record.RR().Name
+	if presented := p.presentName(name); presented != name {
+		return renameRecord(record, presented)
+	}
+	return record
+}
@@ -0,0 +1,661 @@
+package linode
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+)
+
+// ImportMode selects what ImportZone does with the records already in the zone.
+type ImportMode int
+
+const (
+	// ImportAppend adds the records of the zone file and leaves the other records of the zone alone.
+	ImportAppend ImportMode = iota
+	// ImportReplace makes the zone hold only the records of the zone file, deleting every other record.
+	ImportReplace
+)
+
+func (m ImportMode) String() string {
+	switch m {
+	case ImportAppend:
+		return "append"
+	case ImportReplace:
+		return "replace"
+	}
+	return fmt.Sprintf("ImportMode(%d)", int(m))
+}
+
+// UnsupportedEntry is an entry of a zone file that was not imported.
+type UnsupportedEntry struct {
+	// Line is the line number the entry starts on.
+	Line int
+	// Text is the entry as written in the zone file.
+	Text string
+	// Reason says why the entry was not imported.
+	Reason string
+}
+
+func (e UnsupportedEntry) String() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Reason, e.Text)
+}
+
+// ImportResult is the outcome of ImportZone.
+type ImportResult struct {
+	// Created are the records that were added to the zone.
+	Created []libdns.Record
+	// Updated are existing records that were overwritten in place with records of the zone file.
+	Updated []libdns.Record
+	// Unchanged are the records of the zone that already matched records of the zone file, as they are stored.
+	Unchanged []libdns.Record
+	// Deleted are the records that were removed from the zone by ImportReplace.
+	Deleted []libdns.Record
+	// Unsupported lists the entries of the zone file that were skipped, e.g. the SOA record, which Linode manages.
+	Unsupported []UnsupportedEntry
+}
+
+// ImportZone parses a BIND-style master file with ParseZoneFile and applies its records to the zone. Records without
+// a TTL in the zone file, and stored records with a TTL of 0, have the zone's default TTL.
+//
+// With ImportAppend, records of the file that already exist are left as they are, records that only differ from an
+// existing record in their TTL are updated in place, and the others are created. Every record is attempted; if some
+// of them fail, the result is returned together with a *PartialFailureError.
+//
+// With ImportReplace, the zone is diffed against the file the way SetRecords does for each (Name, Type) pair, but for
+// the whole zone: identical records are kept, changed records are updated in place, and the leftovers are created or
+// deleted, with deletions last. The first failure stops the import; with Transactional set, the changes already made
+// are rolled back.
+//
+// Nothing is changed if the zone file cannot be parsed. Entries that cannot be imported are listed in the result
+// rather than failing the import.
+func (p *Provider) ImportZone(ctx context.Context, zone string, r io.Reader, mode ImportMode) (*ImportResult, error) {
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	records, unsupported, err := ParseZoneFile(r, zone)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse zone file: %w", err)
+	}
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter ImportZone", "zone", zone, "mode", mode, "lenRecords", len(records), "lenUnsupported", len(unsupported))
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	domain, err := p.apiGetDomain(ctx, domainID)
+	if err != nil {
+		err = classifyAPIError(err)
+		p.forgetZoneIfGone(zone, err)
+		return nil, fmt.Errorf("could not get domain %d: %w", domainID, err)
+	}
+	existing, err := p.listAllDomainRecords(ctx, domainID, "")
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return nil, fmt.Errorf("could not list domain records: %w", err)
+	}
+
+	// Give records without a TTL the zone's default, so that they compare equal to the same records with it, and
+	// round the other TTLs the way Linode does when storing them
+	defaultTTL := cmp.Or(domain.TTLSec, linodeDefaultTTL)
+	for i := range existing {
+		if existing[i].TTLSec == 0 {
+			existing[i].TTLSec = defaultTTL
+		}
+	}
+	for i, record := range records {
+		if rr := record.RR(); rr.TTL == 0 {
+			rr.TTL = time.Duration(defaultTTL) * time.Second
+			if parsed, err := rr.Parse(); err == nil {
+				record = parsed
+			}
+		}
+		records[i] = withLinodeTTL(record)
+	}
+
+	var result *ImportResult
+	if mode == ImportReplace {
+		result, err = p.importReplace(ctx, zone, domainID, existing, records)
+	} else {
+		result, err = p.importAppend(ctx, zone, domainID, existing, records)
+	}
+	if result != nil {
+		result.Unsupported = unsupported
+		result.Created = p.presentRecords(result.Created)
+		result.Updated = p.presentRecords(result.Updated)
+		result.Unchanged = p.presentRecords(result.Unchanged)
+		result.Deleted = p.presentRecords(result.Deleted)
+	}
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return result, err
+	}
+	p.logger.Debug("Exit ImportZone", "zone", zone, "domainID", domainID, "lenCreated", len(result.Created),
+		"lenUpdated", len(result.Updated), "lenUnchanged", len(result.Unchanged), "lenDeleted", len(result.Deleted))
+	return result, nil
+}
+
+// importReplace makes the zone hold exactly the records, reusing planRecordChanges and applyRecordPlanOrRollBack.
+func (p *Provider) importReplace(ctx context.Context, zone string, domainID int, existing []linodego.DomainRecord, records []libdns.Record) (*ImportResult, error) {
+	plan, err := p.planRecordChanges(existing, records, func(libdns.RR) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	results, err := p.applyRecordPlanOrRollBack(ctx, zone, domainID, records, plan)
+	if err != nil {
+		return nil, fmt.Errorf("could not replace the records of zone %s: %w", zone, err)
+	}
+	result := &ImportResult{}
+	for _, index := range plan.creates {
+		result.Created = append(result.Created, results[index])
+	}
+	for _, update := range plan.updates {
+		result.Updated = append(result.Updated, results[update.index])
+	}
+	for _, unchanged := range plan.unchanged {
+		result.Unchanged = append(result.Unchanged, results[unchanged.index])
+	}
+	for _, linodeRecord := range plan.deletes {
+		record, err := convertToLibdns(p.logger, &linodeRecord)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		result.Deleted = append(result.Deleted, record)
+	}
+	return result, nil
+}
+
+// importAppend adds the records to the zone, updating the TTL of records that already exist with another TTL.
+func (p *Provider) importAppend(ctx context.Context, zone string, domainID int, existing []linodego.DomainRecord, records []libdns.Record) (*ImportResult, error) {
+	existingRecords := make([]libdns.Record, len(existing))
+	for i, linodeRecord := range existing {
+		record, err := convertToLibdns(p.logger, &linodeRecord)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		existingRecords[i] = record
+	}
+
+	// Match each record against an existing record: first an identical one, then one that only differs in its TTL
+	result := &ImportResult{}
+	used := make([]bool, len(existing))
+	updates := make([]plannedRecord, 0)
+	creates := make([]libdns.Record, 0)
+	for i, record := range records {
+		rr := record.RR()
+		opts, err := convertToDomainRecord(p.logger, record, zone)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to linodego struct: %w", err)
+		}
+		match := -1
+		for j, existingRecord := range existingRecords {
+			if !used[j] && sameRecord(existingRecord.RR(), rr) {
+				match = j
+				break
+			}
+		}
+		if match >= 0 {
+			used[match] = true
+			result.Unchanged = append(result.Unchanged, existingRecords[match])
+			continue
+		}
+		for j, linodeRecord := range existing {
+			if !used[j] && recordMatchesOptions(linodeRecord, opts) {
+				match = j
+				break
+			}
+		}
+		if match >= 0 {
+			used[match] = true
+			updates = append(updates, plannedRecord{index: i, existing: existing[match]})
+			continue
+		}
+		creates = append(creates, record)
+	}
+
+	failed := make([]RecordError, 0)
+	updated := make([]*linodego.DomainRecord, len(updates))
+	errs := p.runConcurrently(len(updates), false, func(i int) error {
+		record, err := p.updateLinodeRecord(ctx, zone, domainID, updates[i].existing.ID, records[updates[i].index])
+		updated[i] = record
+		return err
+	})
+	for i, update := range updates {
+		if errs[i] != nil {
+			failed = append(failed, RecordError{Record: records[update.index], Err: errs[i]})
+			continue
+		}
+		record, err := convertToLibdns(p.logger, updated[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		result.Updated = append(result.Updated, record)
+	}
+
+	created := make([]libdns.Record, len(creates))
+	errs = p.runConcurrently(len(creates), false, func(i int) error {
+		record, err := p.createDomainRecord(ctx, zone, domainID, creates[i])
+		created[i] = record
+		return err
+	})
+	for i, record := range creates {
+		if errs[i] != nil {
+			failed = append(failed, RecordError{Record: record, Err: errs[i]})
+			continue
+		}
+		result.Created = append(result.Created, created[i])
+	}
+	if len(failed) > 0 {
+		return result, &PartialFailureError{Failed: failed}
+	}
+	return result, nil
+}
+
+// ParseZoneFile parses a BIND-style master file (RFC 1035 section 5) into records with names relative to the zone.
+// It understands comments, parentheses, quoted strings, escapes, TTL units such as 1h, and the $ORIGIN and $TTL
+// directives, whose origin defaults to the zone. Records without a TTL get the $TTL value, or else the last TTL
+// given.
+//
+// The A, AAAA, CNAME, MX, NS, PTR, SRV, TXT and CAA records that Linode can store are returned. Other entries are
+// returned as unsupported: the SOA record and Linode's own NS records, which Linode adds to every zone, other types
+// and classes, records outside the zone, and $INCLUDE and $GENERATE directives. Syntax errors are returned with their
+// line number.
+func ParseZoneFile(r io.Reader, zone string) ([]libdns.Record, []UnsupportedEntry, error) {
+	entries, err := readZoneFileEntries(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	parser := zoneFileParser{zone: normalizeZone(zone) + ".", defaultTTL: -1, lastTTL: -1}
+	parser.origin = parser.zone
+	records := make([]libdns.Record, 0, len(entries))
+	unsupported := make([]UnsupportedEntry, 0)
+	for _, entry := range entries {
+		record, err := parser.parse(entry)
+		var skip errUnsupportedEntry
+		switch {
+		case errors.As(err, &skip):
+			unsupported = append(unsupported, UnsupportedEntry{Line: entry.line, Text: entry.text, Reason: string(skip)})
+		case err != nil:
+			return nil, nil, fmt.Errorf("line %d: %w", entry.line, err)
+		case record != nil:
+			records = append(records, record)
+		}
+	}
+	return records, unsupported, nil
+}
+
+// errUnsupportedEntry is returned by zoneFileParser.parse for a valid entry that cannot be imported, with the reason.
+type errUnsupportedEntry string
+
+func (e errUnsupportedEntry) Error() string {
+	return string(e)
+}
+
+// zoneFileEntry is a directive or record of a zone file, split into tokens.
+type zoneFileEntry struct {
+	line int
+	text string
+	// inheritsOwner is set when the entry starts with white space, so that it has the owner of the previous record.
+	inheritsOwner bool
+	tokens        []string
+}
+
+// readZoneFileEntries splits a zone file into entries, joining the lines of an entry in parentheses, dropping
+// comments and quotes, and decoding escapes.
+func readZoneFileEntries(r io.Reader) ([]zoneFileEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	entries := make([]zoneFileEntry, 0)
+	var entry zoneFileEntry
+	depth := 0
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if depth == 0 {
+			entry = zoneFileEntry{line: lineNo, inheritsOwner: line != "" && (line[0] == ' ' || line[0] == '\t')}
+		}
+		var token strings.Builder
+		inToken, inQuotes := false, false
+		endToken := func() {
+			if inToken {
+				entry.tokens = append(entry.tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		}
+		end := len(line)
+	scan:
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case c == '\\':
+				b, n, err := decodeEscape(line[i:])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				token.WriteByte(b)
+				inToken = true
+				i += n - 1
+			case inQuotes:
+				if c == '"' {
+					inQuotes = false
+				} else {
+					token.WriteByte(c)
+				}
+			case c == '"':
+				inQuotes, inToken = true, true
+			case c == ';':
+				end = i
+				break scan
+			case c == ' ' || c == '\t':
+				endToken()
+			case c == '(':
+				endToken()
+				depth++
+			case c == ')':
+				endToken()
+				if depth--; depth < 0 {
+					return nil, fmt.Errorf("line %d: unbalanced parentheses", lineNo)
+				}
+			default:
+				token.WriteByte(c)
+				inToken = true
+			}
+		}
+		if inQuotes {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", lineNo)
+		}
+		endToken()
+		if text := strings.TrimSpace(line[:end]); text != "" {
+			entry.text = strings.TrimSpace(entry.text + " " + text)
+		}
+		if depth == 0 && len(entry.tokens) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", entry.line)
+	}
+	return entries, nil
+}
+
+// decodeEscape decodes the escape at the start of s, either \DDD with a decimal byte value or \X for the character X.
+// It returns the byte and the length of the escape.
+func decodeEscape(s string) (byte, int, error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("incomplete escape at end of line")
+	}
+	if s[1] < '0' || s[1] > '9' {
+		return s[1], 2, nil
+	}
+	if len(s) < 4 {
+		return 0, 0, fmt.Errorf("invalid escape %q", s)
+	}
+	n, err := strconv.ParseUint(s[1:4], 10, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid escape %q", s[:4])
+	}
+	return byte(n), 4, nil
+}
+
+// zoneFileParser turns zone file entries into records, keeping track of the directives and the previous record.
+type zoneFileParser struct {
+	// zone is the zone the records are imported into, normalized and with a trailing dot.
+	zone string
+	// origin is the current $ORIGIN, normalized and with a trailing dot.
+	origin string
+	// defaultTTL is the current $TTL, and lastTTL the TTL of the previous record that gave one, or -1 if not set.
+	defaultTTL time.Duration
+	lastTTL    time.Duration
+	// owner is the owner name of the previous record.
+	owner string
+}
+
+// parse returns the record of an entry, or nil for a directive.
+func (z *zoneFileParser) parse(entry zoneFileEntry) (libdns.Record, error) {
+	tokens := entry.tokens
+	if !entry.inheritsOwner && strings.HasPrefix(tokens[0], "$") {
+		return nil, z.directive(strings.ToUpper(tokens[0]), tokens[1:])
+	}
+
+	if entry.inheritsOwner {
+		if z.owner == "" {
+			return nil, fmt.Errorf("the first record has no owner name")
+		}
+	} else {
+		z.owner = z.absoluteName(tokens[0])
+		tokens = tokens[1:]
+	}
+	ttl, class := time.Duration(-1), "IN"
+	for len(tokens) > 0 {
+		if isZoneFileClass(tokens[0]) {
+			class = strings.ToUpper(tokens[0])
+		} else if parsed, err := parseZoneFileTTL(tokens[0]); err == nil {
+			ttl = parsed
+			z.lastTTL = parsed
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("record has no type")
+	}
+	typ, rdata := strings.ToUpper(tokens[0]), tokens[1:]
+	switch {
+	case ttl >= 0:
+	case z.defaultTTL >= 0:
+		ttl = z.defaultTTL
+	case z.lastTTL >= 0:
+		ttl = z.lastTTL
+	default:
+		ttl = 0
+	}
+
+	if class != "IN" {
+		return nil, errUnsupportedEntry(fmt.Sprintf("class %s is not supported", class))
+	}
+	name, ok := z.relativeName(z.owner)
+	if !ok {
+		return nil, errUnsupportedEntry(fmt.Sprintf("%s is outside the zone %s", z.owner, z.zone))
+	}
+	return z.record(name, ttl, typ, rdata)
+}
+
+// directive applies a $ directive.
+func (z *zoneFileParser) directive(name string, args []string) error {
+	switch name {
+	case "$ORIGIN":
+		if len(args) != 1 {
+			return fmt.Errorf("$ORIGIN needs one name")
+		}
+		z.origin = z.absoluteName(args[0])
+	case "$TTL":
+		if len(args) != 1 {
+			return fmt.Errorf("$TTL needs one TTL")
+		}
+		ttl, err := parseZoneFileTTL(args[0])
+		if err != nil {
+			return err
+		}
+		z.defaultTTL = ttl
+	default:
+		return errUnsupportedEntry(fmt.Sprintf("the %s directive is not supported", name))
+	}
+	return nil
+}
+
+// record builds the record from its fields, with name relative to the zone.
+func (z *zoneFileParser) record(name string, ttl time.Duration, typ string, rdata []string) (libdns.Record, error) {
+	wantFields := func(n int) error {
+		if len(rdata) != n {
+			return fmt.Errorf("%s record needs %d fields, got %d", typ, n, len(rdata))
+		}
+		return nil
+	}
+	switch typ {
+	case "A", "AAAA":
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		ip, err := netip.ParseAddr(rdata[0])
+		if err != nil || ip.Is4() != (typ == "A") {
+			return nil, fmt.Errorf("invalid %s address %q", typ, rdata[0])
+		}
+		return libdns.Address{Name: name, TTL: ttl, IP: ip}, nil
+	case "CNAME":
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		return libdns.CNAME{Name: name, TTL: ttl, Target: z.target(rdata[0])}, nil
+	case "NS":
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		target := z.target(rdata[0])
+		if name == "@" && strings.HasSuffix(strings.ToLower(target), ".linode.com") {
+			return nil, errUnsupportedEntry("Linode adds its own NS records to every zone")
+		}
+		return libdns.NS{Name: name, TTL: ttl, Target: target}, nil
+	case "PTR":
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		return libdns.RR{Name: name, TTL: ttl, Type: typ, Data: z.target(rdata[0])}, nil
+	case "MX":
+		if err := wantFields(2); err != nil {
+			return nil, err
+		}
+		preference, err := strconv.ParseUint(rdata[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MX preference %q", rdata[0])
+		}
+		return libdns.MX{Name: name, TTL: ttl, Preference: uint16(preference), Target: z.target(rdata[1])}, nil
+	case "SRV":
+		if err := wantFields(4); err != nil {
+			return nil, err
+		}
+		var fields [3]uint16
+		for i := range fields {
+			n, err := strconv.ParseUint(rdata[i], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SRV field %q", rdata[i])
+			}
+			fields[i] = uint16(n)
+		}
+		// Linode derives the name of an SRV record from its service and protocol, so that it is always
+		// _service._protocol directly below the zone
+		service, transport, ok := strings.Cut(name, ".")
+		if !ok || strings.Contains(transport, ".") || !strings.HasPrefix(service, "_") || !strings.HasPrefix(transport, "_") {
+			return nil, errUnsupportedEntry("Linode only supports SRV records named _service._protocol directly below the zone")
+		}
+		return libdns.SRV{
+			Service:   service[1:],
+			Transport: transport[1:],
+			Name:      "@",
+			TTL:       ttl,
+			Priority:  fields[0],
+			Weight:    fields[1],
+			Port:      fields[2],
+			Target:    z.target(rdata[3]),
+		}, nil
+	case "TXT":
+		if len(rdata) == 0 {
+			return nil, fmt.Errorf("TXT record needs at least one string")
+		}
+		return libdns.TXT{Name: name, TTL: ttl, Text: strings.Join(rdata, "")}, nil
+	case "CAA":
+		if err := wantFields(3); err != nil {
+			return nil, err
+		}
+		flags, err := strconv.ParseUint(rdata[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAA flags %q", rdata[0])
+		}
+		if flags != 0 {
+			return nil, errUnsupportedEntry("Linode does not support CAA flags")
+		}
+		return libdns.CAA{Name: name, TTL: ttl, Tag: rdata[1], Value: rdata[2]}, nil
+	case "SOA":
+		return nil, errUnsupportedEntry("Linode manages the SOA record; set it with UpdateZone")
+	}
+	return nil, errUnsupportedEntry(fmt.Sprintf("Linode does not support %s records", typ))
+}
+
+// absoluteName returns a name of the zone file as an absolute name, normalized and with a trailing dot.
+func (z *zoneFileParser) absoluteName(name string) string {
+	switch {
+	case name == "@":
+		return z.origin
+	case strings.HasSuffix(name, "."):
+		return normalizeZone(name) + "."
+	}
+	return normalizeZone(name+"."+z.origin) + "."
+}
+
+// relativeName returns an absolute name relative to the zone, or false if it is outside the zone.
+func (z *zoneFileParser) relativeName(name string) (string, bool) {
+	if name == z.zone {
+		return "@", true
+	}
+	if !strings.HasSuffix(name, "."+z.zone) {
+		return "", false
+	}
+	return strings.TrimSuffix(name, "."+z.zone), true
+}
+
+// target returns a host name of the zone file the way Linode stores it, as an absolute name without the trailing dot.
+func (z *zoneFileParser) target(name string) string {
+	if name == "." {
+		return name
+	}
+	return strings.TrimSuffix(z.absoluteName(name), ".")
+}
+
+// isZoneFileClass reports whether token is a class, such as IN.
+func isZoneFileClass(token string) bool {
+	switch strings.ToUpper(token) {
+	case "IN", "CS", "CH", "HS":
+		return true
+	}
+	return false
+}
+
+// parseZoneFileTTL parses a TTL in seconds, or with BIND's units, such as 1h30m.
+func parseZoneFileTTL(s string) (time.Duration, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	if seconds, err := strconv.ParseUint(s, 10, 31); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	units := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	var ttl time.Duration
+	for rest := strings.ToLower(s); rest != ""; {
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		n, err := strconv.ParseUint(rest[:i], 10, 31)
+		unit, ok := units[rest[i]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		ttl += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	return ttl, nil
+}
@@ -0,0 +1,167 @@
+package linodetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page sizes accepted by the Linode API.
+const (
+	defaultPageSize = 100
+	minPageSize     = 25
+	maxPageSize     = 500
+)
+
+// writePage filters items with the request's X-Filter header and writes the requested page of the result, the way
+// Linode's list endpoints do. A positive limit caps the page size.
+func writePage[T any](w http.ResponseWriter, r *http.Request, limit int, items []T) {
+	var filter map[string]any
+	if raw := r.Header.Get("X-Filter"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &filter); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid X-Filter: %v", err))
+			return
+		}
+	}
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		ok, err := matchFilter(item, filter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+
+	page, pageSize := 1, defaultPageSize
+	if raw := r.URL.Query().Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "page must be a positive integer")
+			return
+		}
+		page = n
+	}
+	if raw := r.URL.Query().Get("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < minPageSize || n > maxPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("page_size must be between %d and %d", minPageSize, maxPageSize))
+			return
+		}
+		pageSize = n
+	}
+	if limit > 0 && pageSize > limit {
+		pageSize = limit
+	}
+
+	pages := max(1, (len(matched)+pageSize-1)/pageSize)
+	start := min(len(matched), (page-1)*pageSize)
+	end := min(len(matched), start+pageSize)
+	writeJSON(w, map[string]any{"data": matched[start:end], "page": page, "pages": pages, "results": len(matched)})
+}
+
+// matchFilter reports whether item, as rendered to JSON, satisfies a Linode X-Filter. Supported are equality on a
+// field (containment for list fields such as tags), the +and and +or combinators, and the +neq, +contains, +gt, +gte,
+// +lt and +lte operators. Ordering keys are accepted and ignored; results are always ordered by ID.
+func matchFilter(item any, filter map[string]any) (bool, error) {
+	if len(filter) == 0 {
+		return true, nil
+	}
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, err
+	}
+	return matchFields(fields, filter)
+}
+
+func matchFields(fields, filter map[string]any) (bool, error) {
+	for key, want := range filter {
+		switch key {
+		case "+order_by", "+order":
+			continue
+		case "+and", "+or":
+			clauses, ok := want.([]any)
+			if !ok {
+				return false, fmt.Errorf("%s must be a list of filters", key)
+			}
+			matched := 0
+			for _, clause := range clauses {
+				clauseFilter, ok := clause.(map[string]any)
+				if !ok {
+					return false, fmt.Errorf("%s must be a list of filters", key)
+				}
+				ok, err := matchFields(fields, clauseFilter)
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					matched++
+				}
+			}
+			if (key == "+and" && matched != len(clauses)) || (key == "+or" && matched == 0) {
+				return false, nil
+			}
+		default:
+			ok, err := matchField(fields[key], want)
+			if err != nil {
+				return false, fmt.Errorf("cannot filter on %s: %w", key, err)
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchField(got, want any) (bool, error) {
+	operators, ok := want.(map[string]any)
+	if !ok {
+		if list, ok := got.([]any); ok {
+			for _, element := range list {
+				if equalValues(element, want) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return equalValues(got, want), nil
+	}
+	for operator, operand := range operators {
+		var ok bool
+		switch operator {
+		case "+neq":
+			ok = !equalValues(got, operand)
+		case "+contains":
+			ok = strings.Contains(fmt.Sprint(got), fmt.Sprint(operand))
+		case "+gt", "+gte", "+lt", "+lte":
+			g, gok := got.(float64)
+			o, ook := operand.(float64)
+			if !gok || !ook {
+				return false, fmt.Errorf("%s needs numbers", operator)
+			}
+			ok = map[string]bool{"+gt": g > o, "+gte": g >= o, "+lt": g < o, "+lte": g <= o}[operator]
+		default:
+			return false, fmt.Errorf("unsupported operator %s", operator)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func equalValues(got, want any) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return fmt.Sprint(got) == fmt.Sprint(want)
+}
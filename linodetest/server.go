@@ -0,0 +1,569 @@
+// Package linodetest provides an in-memory fake of the Linode Domains API, so that code using linodego or the
+// libdns provider can be tested without Linode access.
+package linodetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/linode/linodego"
+)
+
+// Server is an httptest server implementing the /v4/domains and /v4/domains/{id}/records endpoints of the Linode
+// API, as well as /v4/profile and /v4/profile/grants. Point linodego.Client.SetBaseURL, or the APIURL of a Provider,
+// at its URL with API version "v4".
+//
+// Lists honour the X-Filter header and the page and page_size query parameters. Like Linode, the fake rejects A and
+// AAAA records that duplicate an existing record, and CNAME records that share a name with any other record. It does
+// not check the token unless RequireToken is called, and it reports the token's OAuth scopes in the X-OAuth-Scopes
+// header of every response.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	domains     map[int]linodego.Domain
+	records     map[int]map[int]linodego.DomainRecord
+	failStatus  int
+	failMatch   func(r *http.Request) bool
+	loseStatus  int
+	loseMatch   func(r *http.Request) bool
+	beforeHook  func(r *http.Request)
+	maxPageSize int
+	token       string
+	scopes      string
+	profile     linodego.Profile
+	grants      *linodego.UserGrants
+}
+
+// NewServer starts a fake API server with no domains. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		nextID:  1,
+		domains: make(map[int]linodego.Domain),
+		records: make(map[int]map[int]linodego.DomainRecord),
+		scopes:  "*",
+		profile: linodego.Profile{UID: 1, Username: "linodetest", Email: "linodetest@example.com"},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddDomain creates an active master domain without validation and returns its ID. Unlike the API, it allows
+// several domains with the same name.
+func (s *Server) AddDomain(domain string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insertDomain(linodego.Domain{
+		Domain:   domain,
+		Type:     linodego.DomainTypeMaster,
+		Status:   linodego.DomainStatusActive,
+		SOAEmail: "hostmaster@" + domain,
+	})
+}
+
+// RemoveDomain deletes the domain and its records, as if it had been removed by another API client.
+func (s *Server) RemoveDomain(domainID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.domains, domainID)
+	delete(s.records, domainID)
+}
+
+// Domain returns the domain with the given ID.
+func (s *Server) Domain(domainID int) (linodego.Domain, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	domain, ok := s.domains[domainID]
+	return domain, ok
+}
+
+// Domains returns every domain ordered by ID.
+func (s *Server) Domains() []linodego.Domain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedByID(s.domains, func(domain linodego.Domain) int { return domain.ID })
+}
+
+// AddRecord stores record in the domain as-is, without validation, and returns its ID.
+func (s *Server) AddRecord(domainID int, record linodego.DomainRecord) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.ID = s.nextID
+	s.nextID++
+	s.records[domainID][record.ID] = record
+	return record.ID
+}
+
+// CreateRecord stores a record in the domain the way the API would create it from opts, e.g. deriving the name of
+// SRV records from their service and protocol, and returns it.
+func (s *Server) CreateRecord(domainID int, opts linodego.DomainRecordCreateOptions) (linodego.DomainRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.domains[domainID]; !ok {
+		return linodego.DomainRecord{}, fmt.Errorf("domain %d does not exist", domainID)
+	}
+	record := RecordFromOptions(linodego.DomainRecordUpdateOptions(opts))
+	if reason := s.recordConflict(domainID, 0, record); reason != "" {
+		return linodego.DomainRecord{}, fmt.Errorf("%s", reason)
+	}
+	record.ID = s.nextID
+	s.nextID++
+	s.records[domainID][record.ID] = record
+	return record, nil
+}
+
+// Records returns the records of the domain ordered by ID.
+func (s *Server) Records(domainID int) []linodego.DomainRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedByID(s.records[domainID], func(record linodego.DomainRecord) int { return record.ID })
+}
+
+// FailOn makes every request for which match returns true fail with the given HTTP status. A nil match stops the
+// injected failures. Note that linodego itself retries some statuses, such as 429 and 503.
+func (s *Server) FailOn(status int, match func(r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failStatus = status
+	s.failMatch = match
+}
+
+// LoseResponseOn makes every request for which match returns true take effect as usual, but respond with an error
+// with the given HTTP status, as if the response had been lost on its way back. A nil match stops the lost responses.
+func (s *Server) LoseResponseOn(status int, match func(r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loseStatus = status
+	s.loseMatch = match
+}
+
+// BeforeRequest makes hook run at the start of every request, outside the server's lock, so that it may block or
+// count requests. A nil hook removes it.
+func (s *Server) BeforeRequest(hook func(r *http.Request)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beforeHook = hook
+}
+
+// SetMaxPageSize caps the number of results per page regardless of the requested page_size, so that pagination can
+// be exercised with few records. Zero removes the cap.
+func (s *Server) SetMaxPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPageSize = n
+}
+
+// RequireToken makes every request without the given bearer token fail with 401 Unauthorized. An empty token stops
+// the check.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetScopes sets the OAuth scopes of the token, "*" by default, e.g. "domains:read_only linodes:read_write". An
+// empty value leaves out the X-OAuth-Scopes header.
+func (s *Server) SetScopes(scopes string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes = scopes
+}
+
+// SetProfile sets the profile returned by /v4/profile. Its Restricted field is overridden by SetGrants.
+func (s *Server) SetProfile(profile linodego.Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile = profile
+}
+
+// SetGrants makes the user of the token a restricted user with the given grants. Nil, the default, makes the user
+// unrestricted, in which case /v4/profile/grants responds with 204 No Content like Linode does.
+func (s *Server) SetGrants(grants *linodego.UserGrants) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants = grants
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	hook := s.beforeHook
+	s.mu.Unlock()
+	if hook != nil {
+		hook(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "Invalid Token")
+		return
+	}
+	if s.scopes != "" {
+		w.Header().Set("X-OAuth-Scopes", s.scopes)
+	}
+	if s.failMatch != nil && s.failMatch(r) {
+		writeError(w, s.failStatus, "injected failure")
+		return
+	}
+	if s.loseMatch != nil && s.loseMatch(r) {
+		s.route(httptest.NewRecorder(), r)
+		writeError(w, s.loseStatus, "injected failure after the request took effect")
+		return
+	}
+	s.route(w, r)
+}
+
+// route dispatches the request to its handler. The caller holds the lock.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "v4" && parts[1] == "profile" {
+		s.serveProfile(w, r, parts[2:])
+		return
+	}
+	if len(parts) < 2 || parts[0] != "v4" || parts[1] != "domains" {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	switch len(parts) {
+	case 2:
+		switch r.Method {
+		case http.MethodGet:
+			s.listDomains(w, r)
+		case http.MethodPost:
+			s.createDomain(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	case 3:
+		domainID, ok := s.lookupDomain(w, parts[2])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, s.domains[domainID])
+		case http.MethodPut:
+			s.updateDomain(w, r, domainID)
+		case http.MethodDelete:
+			delete(s.domains, domainID)
+			delete(s.records, domainID)
+			writeJSON(w, struct{}{})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	case 4, 5:
+		if parts[3] != "records" {
+			writeError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		domainID, ok := s.lookupDomain(w, parts[2])
+		if !ok {
+			return
+		}
+		if len(parts) == 4 {
+			switch r.Method {
+			case http.MethodGet:
+				s.listRecords(w, r, domainID)
+			case http.MethodPost:
+				s.createRecord(w, r, domainID)
+			default:
+				writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+			return
+		}
+		recordID, err := strconv.Atoi(parts[4])
+		if _, exists := s.records[domainID][recordID]; err != nil || !exists {
+			writeError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, s.records[domainID][recordID])
+		case http.MethodPut:
+			s.updateRecord(w, r, domainID, recordID)
+		case http.MethodDelete:
+			delete(s.records[domainID], recordID)
+			writeJSON(w, struct{}{})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// serveProfile serves /v4/profile and its subpaths, of which only grants is implemented.
+func (s *Server) serveProfile(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	switch {
+	case len(parts) == 0:
+		profile := s.profile
+		profile.Restricted = s.grants != nil
+		writeJSON(w, profile)
+	case len(parts) == 1 && parts[0] == "grants":
+		if s.grants == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, s.grants)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) lookupDomain(w http.ResponseWriter, rawID string) (int, bool) {
+	domainID, err := strconv.Atoi(rawID)
+	if _, exists := s.domains[domainID]; err != nil || !exists {
+		writeError(w, http.StatusNotFound, "Not found")
+		return 0, false
+	}
+	return domainID, true
+}
+
+func (s *Server) insertDomain(domain linodego.Domain) int {
+	domain.ID = s.nextID
+	s.nextID++
+	s.domains[domain.ID] = domain
+	s.records[domain.ID] = make(map[int]linodego.DomainRecord)
+	return domain.ID
+}
+
+func (s *Server) listDomains(w http.ResponseWriter, r *http.Request) {
+	writePage(w, r, s.maxPageSize, sortedByID(s.domains, func(domain linodego.Domain) int { return domain.ID }))
+}
+
+func (s *Server) createDomain(w http.ResponseWriter, r *http.Request) {
+	var opts linodego.DomainCreateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	domain := linodego.Domain{
+		Domain:      opts.Domain,
+		Type:        opts.Type,
+		Status:      opts.Status,
+		Description: opts.Description,
+		SOAEmail:    opts.SOAEmail,
+		RetrySec:    opts.RetrySec,
+		MasterIPs:   opts.MasterIPs,
+		AXfrIPs:     opts.AXfrIPs,
+		Tags:        opts.Tags,
+		ExpireSec:   opts.ExpireSec,
+		RefreshSec:  opts.RefreshSec,
+		TTLSec:      opts.TTLSec,
+	}
+	if domain.Status == "" {
+		domain.Status = linodego.DomainStatusActive
+	}
+	if reason := s.domainInvalid(0, domain); reason != "" {
+		writeError(w, http.StatusBadRequest, reason)
+		return
+	}
+	domainID := s.insertDomain(domain)
+	writeJSON(w, s.domains[domainID])
+}
+
+func (s *Server) updateDomain(w http.ResponseWriter, r *http.Request, domainID int) {
+	var opts linodego.DomainUpdateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	domain := s.domains[domainID]
+	if opts.Domain != "" {
+		domain.Domain = opts.Domain
+	}
+	if opts.Type != "" {
+		domain.Type = opts.Type
+	}
+	if opts.Status != "" {
+		domain.Status = opts.Status
+	}
+	if opts.Description != "" {
+		domain.Description = opts.Description
+	}
+	if opts.SOAEmail != "" {
+		domain.SOAEmail = opts.SOAEmail
+	}
+	if opts.RetrySec != 0 {
+		domain.RetrySec = opts.RetrySec
+	}
+	if opts.ExpireSec != 0 {
+		domain.ExpireSec = opts.ExpireSec
+	}
+	if opts.RefreshSec != 0 {
+		domain.RefreshSec = opts.RefreshSec
+	}
+	if opts.TTLSec != 0 {
+		domain.TTLSec = opts.TTLSec
+	}
+	// These lists are always sent by linodego, so they replace the current values
+	domain.MasterIPs = opts.MasterIPs
+	domain.AXfrIPs = opts.AXfrIPs
+	domain.Tags = opts.Tags
+	if reason := s.domainInvalid(domainID, domain); reason != "" {
+		writeError(w, http.StatusBadRequest, reason)
+		return
+	}
+	s.domains[domainID] = domain
+	writeJSON(w, domain)
+}
+
+// domainInvalid returns why the API would reject domain, or "" if it is acceptable. The domain with ID self is
+// ignored when looking for duplicates.
+func (s *Server) domainInvalid(self int, domain linodego.Domain) string {
+	switch {
+	case domain.Domain == "":
+		return "domain is required"
+	case domain.Type != linodego.DomainTypeMaster && domain.Type != linodego.DomainTypeSlave:
+		return "type must be master or slave"
+	case domain.Type == linodego.DomainTypeMaster && domain.SOAEmail == "":
+		return "soa_email is required for master domains"
+	case domain.Type == linodego.DomainTypeSlave && len(domain.MasterIPs) == 0:
+		return "master_ips is required for slave domains"
+	}
+	for id, other := range s.domains {
+		if id != self && strings.EqualFold(other.Domain, domain.Domain) {
+			return "Domain already exists"
+		}
+	}
+	return ""
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request, domainID int) {
+	writePage(w, r, s.maxPageSize, sortedByID(s.records[domainID], func(record linodego.DomainRecord) int { return record.ID }))
+}
+
+func (s *Server) createRecord(w http.ResponseWriter, r *http.Request, domainID int) {
+	var opts linodego.DomainRecordCreateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	record := RecordFromOptions(linodego.DomainRecordUpdateOptions(opts))
+	if reason := s.recordConflict(domainID, 0, record); reason != "" {
+		writeError(w, http.StatusBadRequest, reason)
+		return
+	}
+	record.ID = s.nextID
+	s.nextID++
+	s.records[domainID][record.ID] = record
+	writeJSON(w, record)
+}
+
+func (s *Server) updateRecord(w http.ResponseWriter, r *http.Request, domainID, recordID int) {
+	var opts linodego.DomainRecordUpdateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	record := RecordFromOptions(opts)
+	if record.Type == "" {
+		record.Type = s.records[domainID][recordID].Type
+	}
+	if reason := s.recordConflict(domainID, recordID, record); reason != "" {
+		writeError(w, http.StatusBadRequest, reason)
+		return
+	}
+	record.ID = recordID
+	s.records[domainID][recordID] = record
+	writeJSON(w, record)
+}
+
+// recordConflict returns why the API would reject record, or "" if it is acceptable. The record with ID self is
+// ignored, so that a record can be updated in place.
+func (s *Server) recordConflict(domainID, self int, record linodego.DomainRecord) string {
+	if record.Type == "" {
+		return "type is required"
+	}
+	for id, other := range s.records[domainID] {
+		if id == self || !strings.EqualFold(other.Name, record.Name) {
+			continue
+		}
+		if record.Type == linodego.RecordTypeCNAME || other.Type == linodego.RecordTypeCNAME {
+			return "Record conflict - CNAMES must be unique"
+		}
+		if (record.Type == linodego.RecordTypeA || record.Type == linodego.RecordTypeAAAA) &&
+			other.Type == record.Type && strings.EqualFold(other.Target, record.Target) {
+			return "Record conflict - Records must be unique"
+		}
+	}
+	return ""
+}
+
+// RecordFromOptions builds a record the way Linode stores it, e.g. SRV names are derived from service and protocol,
+// and TTLs are rounded up to the values Linode supports.
+func RecordFromOptions(opts linodego.DomainRecordUpdateOptions) linodego.DomainRecord {
+	record := linodego.DomainRecord{
+		Type:     opts.Type,
+		Name:     opts.Name,
+		Target:   opts.Target,
+		TTLSec:   roundTTL(opts.TTLSec),
+		Service:  opts.Service,
+		Protocol: opts.Protocol,
+		Tag:      opts.Tag,
+	}
+	if opts.Priority != nil {
+		record.Priority = *opts.Priority
+	}
+	if opts.Weight != nil {
+		record.Weight = *opts.Weight
+	}
+	if opts.Port != nil {
+		record.Port = *opts.Port
+	}
+	if record.Type == linodego.RecordTypeSRV && record.Service != nil && record.Protocol != nil {
+		record.Name = "_" + *record.Service + "._" + *record.Protocol
+		if opts.Name != "" {
+			record.Name += "." + opts.Name
+		}
+	}
+	return record
+}
+
+// supportedTTLs are the record TTLs Linode accepts, in seconds.
+var supportedTTLs = []int{30, 120, 300, 3600, 7200, 14400, 28800, 57600, 86400, 172800, 345600, 604800, 1209600, 2419200}
+
+// roundTTL rounds a TTL up to the next one Linode supports. 0 stands for the default TTL of the domain.
+func roundTTL(ttl int) int {
+	if ttl <= 0 {
+		return 0
+	}
+	for _, supported := range supportedTTLs {
+		if ttl <= supported {
+			return supported
+		}
+	}
+	return supportedTTLs[len(supportedTTLs)-1]
+}
+
+func sortedByID[T any](items map[int]T, id func(T) int) []T {
+	sorted := make([]T, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, item)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return id(sorted[i]) < id(sorted[j]) })
+	return sorted
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"reason": reason}}})
+}
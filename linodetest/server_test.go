@@ -0,0 +1,205 @@
+package linodetest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/linode/linodego"
+
+	"github.com/HugoKlepsch/libdns-linode/linodetest"
+)
+
+func newClient(t *testing.T) (*linodetest.Server, linodego.Client) {
+	t.Helper()
+	s := linodetest.NewServer()
+	t.Cleanup(s.Close)
+	c := linodego.NewClient(s.Client())
+	c.SetToken("fake-token")
+	c.SetBaseURL(s.URL)
+	c.SetAPIVersion("v4")
+	return s, c
+}
+
+func TestFilter(t *testing.T) {
+	s, c := newClient(t)
+	domainID := s.AddDomain("example.com")
+	for _, record := range []linodego.DomainRecord{
+		{Type: linodego.RecordTypeA, Name: "www", Target: "192.0.2.1"},
+		{Type: linodego.RecordTypeAAAA, Name: "www", Target: "2001:db8::1"},
+		{Type: linodego.RecordTypeTXT, Name: "www", Target: "hello"},
+		{Type: linodego.RecordTypeA, Name: "mail", Target: "192.0.2.2", TTLSec: 3600},
+	} {
+		s.AddRecord(domainID, record)
+	}
+
+	tests := []struct {
+		filter string
+		want   int
+	}{
+		{filter: `{"name": "www"}`, want: 3},
+		{filter: `{"name": "www", "type": "A"}`, want: 1},
+		{filter: `{"+and": [{"name": "www"}, {"+or": [{"type": "A"}, {"type": "AAAA"}]}]}`, want: 2},
+		{filter: `{"type": {"+neq": "A"}}`, want: 2},
+		{filter: `{"ttl_sec": {"+gte": 3600}}`, want: 1},
+		{filter: `{"name": "ftp"}`, want: 0},
+	}
+	for _, tt := range tests {
+		records, err := c.ListDomainRecords(context.Background(), domainID, linodego.NewListOptions(0, tt.filter))
+		if err != nil {
+			t.Errorf("ListDomainRecords(%s) returned error: %v", tt.filter, err)
+			continue
+		}
+		if len(records) != tt.want {
+			t.Errorf("ListDomainRecords(%s) returned %d records, want %d", tt.filter, len(records), tt.want)
+		}
+	}
+
+	if _, err := c.ListDomainRecords(context.Background(), domainID, linodego.NewListOptions(0, `{"name": {"+regex": "w"}}`)); err == nil {
+		t.Error("expected an unsupported filter operator to be rejected")
+	}
+}
+
+func TestPagination(t *testing.T) {
+	s, c := newClient(t)
+	domainID := s.AddDomain("example.com")
+	for i := range 60 {
+		s.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeTXT, Name: fmt.Sprintf("txt%d", i), Target: "x"})
+	}
+	ctx := context.Background()
+
+	// linodego follows every page when no page is requested
+	records, err := c.ListDomainRecords(ctx, domainID, &linodego.ListOptions{PageSize: 25})
+	if err != nil {
+		t.Fatalf("ListDomainRecords returned error: %v", err)
+	}
+	if len(records) != 60 {
+		t.Errorf("got %d records over all pages, want 60", len(records))
+	}
+
+	opts := &linodego.ListOptions{PageOptions: &linodego.PageOptions{Page: 3}, PageSize: 25}
+	records, err = c.ListDomainRecords(ctx, domainID, opts)
+	if err != nil {
+		t.Fatalf("ListDomainRecords returned error: %v", err)
+	}
+	if len(records) != 10 || opts.Pages != 3 || opts.Results != 60 {
+		t.Errorf("page 3 has %d records of %d pages and %d results, want 10, 3 and 60", len(records), opts.Pages, opts.Results)
+	}
+
+	s.SetMaxPageSize(7)
+	opts = &linodego.ListOptions{PageOptions: &linodego.PageOptions{Page: 1}}
+	if _, err := c.ListDomainRecords(ctx, domainID, opts); err != nil {
+		t.Fatalf("ListDomainRecords returned error: %v", err)
+	}
+	if opts.Pages != 9 {
+		t.Errorf("got %d pages with a maximum page size of 7, want 9", opts.Pages)
+	}
+
+	if _, err := c.ListDomainRecords(ctx, domainID, &linodego.ListOptions{PageSize: 5}); err == nil {
+		t.Error("expected a page size below the API minimum to be rejected")
+	}
+}
+
+func TestRecordConflicts(t *testing.T) {
+	s, c := newClient(t)
+	domainID := s.AddDomain("example.com")
+	ctx := context.Background()
+	create := func(recordType linodego.DomainRecordType, name, target string) error {
+		_, err := c.CreateDomainRecord(ctx, domainID, linodego.DomainRecordCreateOptions{Type: recordType, Name: name, Target: target})
+		return err
+	}
+
+	if err := create(linodego.RecordTypeA, "www", "192.0.2.1"); err != nil {
+		t.Fatalf("CreateDomainRecord returned error: %v", err)
+	}
+	if err := create(linodego.RecordTypeA, "www", "192.0.2.1"); err == nil {
+		t.Error("expected a duplicate A record to be rejected")
+	}
+	if err := create(linodego.RecordTypeCNAME, "www", "example.net"); err == nil {
+		t.Error("expected a CNAME next to an A record to be rejected")
+	}
+	if err := create(linodego.RecordTypeTXT, "www", "hello"); err != nil {
+		t.Errorf("CreateDomainRecord returned error: %v", err)
+	}
+	if err := create(linodego.RecordTypeTXT, "www", "hello"); err != nil {
+		t.Errorf("expected duplicate TXT records to be allowed, got: %v", err)
+	}
+}
+
+func TestDomains(t *testing.T) {
+	s, c := newClient(t)
+	ctx := context.Background()
+
+	domain, err := c.CreateDomain(ctx, linodego.DomainCreateOptions{Domain: "example.com", Type: linodego.DomainTypeMaster, SOAEmail: "hostmaster@example.com"})
+	if err != nil {
+		t.Fatalf("CreateDomain returned error: %v", err)
+	}
+	if domain.Status != linodego.DomainStatusActive {
+		t.Errorf("new domain status = %q, want active", domain.Status)
+	}
+	if _, err := c.CreateDomain(ctx, linodego.DomainCreateOptions{Domain: "example.com", Type: linodego.DomainTypeMaster, SOAEmail: "hostmaster@example.com"}); err == nil {
+		t.Error("expected a duplicate domain to be rejected")
+	}
+	if _, err := c.CreateDomain(ctx, linodego.DomainCreateOptions{Domain: "example.net", Type: linodego.DomainTypeMaster}); err == nil {
+		t.Error("expected a master domain without an SOA email to be rejected")
+	}
+
+	updated, err := c.UpdateDomain(ctx, domain.ID, linodego.DomainUpdateOptions{Tags: []string{"prod"}})
+	if err != nil {
+		t.Fatalf("UpdateDomain returned error: %v", err)
+	}
+	if updated.SOAEmail != domain.SOAEmail || len(updated.Tags) != 1 {
+		t.Errorf("updated domain = %+v, want the SOA email kept and the tag added", updated)
+	}
+
+	domains, err := c.ListDomains(ctx, linodego.NewListOptions(0, `{"tags": "prod"}`))
+	if err != nil || len(domains) != 1 {
+		t.Errorf("ListDomains by tag = %v, %v, want the tagged domain", domains, err)
+	}
+
+	if err := c.DeleteDomain(ctx, domain.ID); err != nil {
+		t.Fatalf("DeleteDomain returned error: %v", err)
+	}
+	if _, ok := s.Domain(domain.ID); ok {
+		t.Error("domain still exists after DeleteDomain")
+	}
+
+	s.FailOn(http.StatusForbidden, func(r *http.Request) bool { return r.Method == http.MethodGet })
+	if _, err := c.ListDomains(ctx, nil); err == nil {
+		t.Error("expected the injected failure to be returned")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	ctx := context.Background()
+	s, c := newClient(t)
+
+	profile, err := c.GetProfile(ctx)
+	if err != nil {
+		t.Fatalf("GetProfile returned error: %v", err)
+	}
+	if profile.Username != "linodetest" || profile.Restricted {
+		t.Errorf("profile = %+v, want the unrestricted default user", profile)
+	}
+	resp, err := c.R(ctx).Get("profile/grants")
+	if err != nil || resp.StatusCode() != http.StatusNoContent || resp.Header().Get("X-OAuth-Scopes") != "*" {
+		t.Errorf("grants of an unrestricted user = %v, %v; want 204 with all scopes", resp, err)
+	}
+
+	s.SetGrants(&linodego.UserGrants{Domain: []linodego.GrantedEntity{{ID: 1, Permissions: linodego.AccessLevelReadOnly}}})
+	grants := &linodego.UserGrants{}
+	if _, err := c.R(ctx).SetResult(grants).Get("profile/grants"); err != nil || len(grants.Domain) != 1 {
+		t.Errorf("grants = %+v, %v; want the domain grant", grants, err)
+	}
+	if profile, err := c.GetProfile(ctx); err != nil || !profile.Restricted {
+		t.Errorf("profile = %+v, %v; want a restricted user", profile, err)
+	}
+
+	s.RequireToken("another-token")
+	var apiErr *linodego.Error
+	if _, err := c.GetProfile(ctx); !errors.As(err, &apiErr) || apiErr.Code != http.StatusUnauthorized {
+		t.Errorf("GetProfile with the wrong token returned %v, want 401", err)
+	}
+}
@@ -0,0 +1,50 @@
+package linode
+
+import (
+	"context"
+
+	"github.com/linode/linodego"
+)
+
+// forEachPage fetches the pages of a Linode list endpoint one at a time, with Provider.PageSize results per page,
+// and passes the results of each page to fn before fetching the next one. filter is an X-Filter, or "" for none.
+// API errors are classified; errors returned by fn are returned as-is.
+func forEachPage[T any](ctx context.Context, p *Provider, filter string, list func(context.Context, *linodego.ListOptions) ([]T, error), fn func([]T) error) error {
+	for page := 1; ; page++ {
+		opts := &linodego.ListOptions{PageOptions: &linodego.PageOptions{Page: page}, PageSize: p.PageSize, Filter: filter}
+		items, err := list(ctx, opts)
+		if err != nil {
+			return classifyAPIError(err)
+		}
+		p.logger.Debug("fetched page", "page", page, "pages", opts.Pages, "results", opts.Results, "lenItems", len(items))
+		if err := fn(items); err != nil {
+			return err
+		}
+		if page >= opts.Pages {
+			return nil
+		}
+	}
+}
+
+// listAllDomains returns every domain selected by the X-Filter, or every domain if filter is "".
+func (p *Provider) listAllDomains(ctx context.Context, filter string) ([]linodego.Domain, error) {
+	domains := make([]linodego.Domain, 0)
+	err := forEachPage(ctx, p, filter, p.apiListDomains, func(page []linodego.Domain) error {
+		domains = append(domains, page...)
+		return nil
+	})
+	return domains, err
+}
+
+// listAllDomainRecords returns every record of the domain selected by the X-Filter, or every record if filter is "".
+func (p *Provider) listAllDomainRecords(ctx context.Context, domainID int, filter string) ([]linodego.DomainRecord, error) {
+	list := func(ctx context.Context, opts *linodego.ListOptions) ([]linodego.DomainRecord, error) {
+		return p.apiListDomainRecords(ctx, domainID, opts)
+	}
+	records := make([]linodego.DomainRecord, 0)
+	err := forEachPage(ctx, p, filter, list, func(page []linodego.DomainRecord) error {
+		records = append(records, page...)
+		return nil
+	})
+	return records, err
+}
@@ -9,13 +9,24 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
 	"github.com/linode/linodego"
+	"golang.org/x/time/rate"
 )
 
+// DefaultFilteredLookupThreshold is used when Provider.FilteredLookupThreshold is zero. A handful of filtered
+// requests are cheaper than listing a zone of more than a few hundred records, which takes one request per page.
+const DefaultFilteredLookupThreshold = 5
+
 // Provider facilitates DNS record manipulation with Linode.
+//
+// Settings that are left empty fall back to the environment: the API token to APITokenFile, LINODE_TOKEN,
+// LINODE_DNS_PAT and then a profile of the Linode config file, the API URL to LINODE_URL and the API version to
+// LINODE_API_VERSION. See NewProviderFromEnv.
 type Provider struct {
 	// APIToken is the Linode Personal Access Token, see https://cloud.linode.com/profile/tokens.
 	APIToken string `json:"api_token,omitempty"`
@@ -23,143 +34,342 @@ type Provider struct {
 	APIURL string `json:"api_url,omitempty"`
 	// APIVersion is the Linode API version, i.e. "v4".
 	APIVersion string `json:"api_version,omitempty"`
+	// APITokenFile is the path of a file holding the API token, such as a Docker or Kubernetes secret. It is read
+	// when APIToken is empty.
+	APITokenFile string `json:"api_token_file,omitempty"`
+	// ConfigProfile is the profile of the Linode config file to use when no token is given otherwise. Empty means
+	// the LINODE_PROFILE environment variable, or else "default".
+	ConfigProfile string `json:"config_profile,omitempty"`
+
+	// Transactional makes SetRecords and SyncZone undo the changes they already made when a later change fails, so
+	// that the zone is left as it was found. Records that have to be recreated by the rollback get new Linode record
+	// IDs.
+	Transactional bool `json:"transactional,omitempty"`
+	// SkipUnsupportedTypes makes AppendRecords silently skip records of types Linode does not support, such as
+	// HTTPS/SVCB, instead of reporting them as failed.
+	SkipUnsupportedTypes bool `json:"skip_unsupported_types,omitempty"`
+
+	// ZoneCacheTTL is how long the domain ID of a zone is cached, which saves a domain lookup on every call. Zero
+	// means DefaultZoneCacheTTL and a negative value disables the cache. Entries are also dropped when Linode reports
+	// that the domain no longer exists.
+	ZoneCacheTTL time.Duration `json:"zone_cache_ttl,omitempty"`
+
+	// FilteredLookupThreshold is the largest number of records for which DeleteRecords looks each of them up with a
+	// filtered request instead of listing the whole zone. Zero means DefaultFilteredLookupThreshold and a negative
+	// value always lists the whole zone.
+	FilteredLookupThreshold int `json:"filtered_lookup_threshold,omitempty"`
+
+	// PageSize is the number of results requested per page when listing zones and records. Linode accepts 25 to 500
+	// and uses 100 when PageSize is zero. Pages are fetched one at a time, so larger pages mean fewer requests.
+	PageSize int `json:"page_size,omitempty"`
+
+	// Retry controls how requests that fail because of rate limiting or transient Linode problems are retried.
+	Retry RetryPolicy `json:"retry,omitempty"`
+
+	// RateLimit is the number of requests per second the provider sends at most, on average, including retries. Zero
+	// disables the limit.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+	// RateLimitBurst is the number of requests that may be sent at once before RateLimit applies. Zero means RateLimit
+	// rounded up, and at least 1.
+	RateLimitBurst int `json:"rate_limit_burst,omitempty"`
+	// ShareRateLimit makes every Provider in the process that uses the same APIToken share a single rate limit, as
+	// Linode counts requests per token. The limit is created by the first of these Providers to send a request, and
+	// its RateLimit and RateLimitBurst apply to all of them.
+	ShareRateLimit bool `json:"share_rate_limit,omitempty"`
 
+	// Workers is the number of record creations, updates and deletions that AppendRecords, SetRecords and
+	// DeleteRecords send at the same time, which speeds up changing many records at once. Zero means 1, i.e. one at a
+	// time. Records are returned in the same order either way. Consider setting RateLimit as well.
+	Workers int `json:"workers,omitempty"`
+
+	// HTTPClient is the HTTP client used to talk to Linode, e.g. to configure a proxy. If nil, a client with the
+	// default transport is used. It is copied, not modified.
+	HTTPClient *http.Client `json:"-"`
+	// Timeout limits each HTTP request, including reading the response. Zero means the timeout of HTTPClient, if it
+	// has one, or else DefaultTimeout; a negative value disables the timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// UserAgent is the User-Agent header sent with every request. If empty, it names libdns-linode and linodego with
+	// their versions.
+	UserAgent string `json:"user_agent,omitempty"`
+	// WrapTransport, if set, is called once with the transport of the HTTP client, and the transport it returns is
+	// used instead, e.g. to add logging, metrics or tracing to every request.
+	WrapTransport func(http.RoundTripper) http.RoundTripper `json:"-"`
+
+	// ResolveFQDNs lets the zone argument of the record methods be any name inside a zone, e.g.
+	// "_acme-challenge.dev.example.com.", instead of the zone itself. The most specific zone containing the name is
+	// found with FindZone, record names are taken relative to the name as given, and returned records are relative to
+	// it as well. GetRecords and IterateRecords then only return the records at or below the name.
+	ResolveFQDNs bool `json:"resolve_fqdns,omitempty"`
+
+	// NameForm is the form of the zone and record names the provider returns: NameFormASCII, the default, returns
+	// internationalized names in their "xn--" form as Linode stores them, and NameFormUnicode in their Unicode form.
+	// Names passed to the provider may be in either form, and are compared case-insensitively.
+	NameForm NameForm `json:"name_form,omitempty"`
+
+	// Logger receives the provider's log output. If nil, slog.Default() is used, unless DebugLogsEnabled is set.
+	Logger *slog.Logger `json:"-"`
+	// DebugLogsEnabled makes the provider write debug logs to stdout when no Logger is set. It never affects the
+	// process-wide default logger.
 	DebugLogsEnabled bool `json:"debug_logs_enabled,omitempty"`
+	logger           *slog.Logger
 	client           linodego.Client
+	limiter          *rate.Limiter
 	once             sync.Once
-	mutex            sync.Mutex
+	initErr          error
+	mutex            sync.Mutex // guards zoneLocks
+	zoneLocks        map[string]*sync.Mutex
+	zoneCache        zoneCache
 }
 
-func (p *Provider) init(_ context.Context) {
-	slog.Debug("Enter init", "hasToken", p.APIToken != "", "APIURL", p.APIURL, "APIVersion", p.APIVersion)
+// init sets up the provider the first time it is used. If that fails, every call returns the same error.
+func (p *Provider) init(_ context.Context) error {
 	p.once.Do(func() {
-		// Configure global logger based on DebugLogsEnabled
-		level := slog.LevelInfo
-		if p.DebugLogsEnabled {
-			level = slog.LevelDebug
+		// Configure the provider's own logger; the process-wide default logger is left alone
+		switch {
+		case p.Logger != nil:
+			p.logger = p.Logger
+		case p.DebugLogsEnabled:
+			h := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+			p.logger = slog.New(h)
+		default:
+			p.logger = slog.Default()
 		}
-		h := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
-		slog.SetDefault(slog.New(h))
+		p.logger.Debug("Enter init", "hasToken", p.APIToken != "", "hasTokenFile", p.APITokenFile != "", "APIURL", p.APIURL, "APIVersion", p.APIVersion)
 
-		p.client = linodego.NewClient(http.DefaultClient)
-		if p.APIToken != "" {
-			p.client.SetToken(p.APIToken)
-		}
-		if p.APIURL != "" {
-			p.client.SetBaseURL(p.APIURL)
+		creds, err := p.resolveCredentials()
+		if err != nil {
+			p.initErr = err
+			return
 		}
-		if p.APIVersion != "" {
-			p.client.SetAPIVersion(p.APIVersion)
+		p.client = linodego.NewClient(p.httpClient())
+		p.client.SetUserAgent(p.userAgent())
+		if err := creds.configure(&p.client); err != nil {
+			p.initErr = err
+			return
 		}
+		// Requests are retried according to p.Retry instead, which knows which requests are safe to repeat
+		p.client.SetRetryCount(0)
+		p.limiter = p.newLimiter(creds)
 	})
-	slog.Debug("Exit init")
+	p.logger.Debug("Exit init", "err", p.initErr)
+	return p.initErr
+}
+
+// lockZone serializes operations on a single zone while leaving other zones free to proceed in parallel. It returns
+// the function that releases the lock.
+func (p *Provider) lockZone(zone string) (unlock func()) {
+	key := normalizeZone(zone)
+	p.mutex.Lock()
+	if p.zoneLocks == nil {
+		p.zoneLocks = make(map[string]*sync.Mutex)
+	}
+	zoneLock, ok := p.zoneLocks[key]
+	if !ok {
+		zoneLock = &sync.Mutex{}
+		p.zoneLocks[key] = zoneLock
+	}
+	p.mutex.Unlock()
+	zoneLock.Lock()
+	return zoneLock.Unlock
+}
+
+// normalizeZone returns the form of the zone name used to compare zones, so that "Example.com.", "example.com" and
+// the Unicode and ASCII forms of an internationalized name are treated as the same zone.
+func normalizeZone(zone string) string {
+	return strings.TrimSuffix(asciiName(zone), ".")
 }
 
 // ListZones lists all the zones (domains).
 func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.init(ctx)
-	slog.Debug("Enter ListZones")
-	domains, err := p.client.ListDomains(ctx, nil)
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	p.logger.Debug("Enter ListZones")
+	domains, err := p.listAllDomains(ctx, "")
 	if err != nil {
 		return nil, fmt.Errorf("error listing domains: %w", err)
 	}
 	zones := make([]libdns.Zone, 0, len(domains))
 	for _, domain := range domains {
-		zones = append(zones, libdns.Zone{Name: domain.Domain})
+		zones = append(zones, libdns.Zone{Name: p.presentName(domain.Domain)})
 	}
-	slog.Debug("Exit ListZones", "lenZones", len(zones))
+	p.logger.Debug("Exit ListZones", "lenZones", len(zones))
 	return zones, nil
 }
 
 // GetRecords lists all the records in the zone.
 func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.init(ctx)
-	slog.Debug("Enter GetRecords", "zone", zone)
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter GetRecords", "zone", zone)
 	domainID, err := p.getDomainIDByZone(ctx, zone)
 	if err != nil {
-		return nil, fmt.Errorf("error getting domain ID for zone %s: %v", zone, err)
+		return nil, fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
 	}
 	records, err := p.listDomainRecords(ctx, domainID)
 	if err != nil {
+		p.forgetZoneIfGone(zone, err)
 		return nil, fmt.Errorf("error listing domain records: %w", err)
 	}
-	slog.Debug("Exit GetRecords", "zone", zone, "lenRecords", len(records))
+	records = p.presentRecords(route.fromZoneAll(records))
+	p.logger.Debug("Exit GetRecords", "zone", zone, "lenRecords", len(records))
 	return records, nil
 }
 
-// AppendRecords adds records to the zone. It returns the records that were added.
+// IterateRecords calls fn for every record in the zone, fetching the records one page at a time rather than all at
+// once. If fn returns an error, the iteration stops and that error is returned as-is. The zone is locked while
+// iterating, so fn must not call other methods of the Provider for the same zone.
+func (p *Provider) IterateRecords(ctx context.Context, zone string, fn func(libdns.Record) error) error {
+	if err := p.init(ctx); err != nil {
+		return err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter IterateRecords", "zone", zone)
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	err = p.iterateDomainRecords(ctx, domainID, func(record libdns.Record) error {
+		if record, ok := route.fromZone(record); ok {
+			return fn(p.presentRecord(record))
+		}
+		return nil
+	})
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return err
+	}
+	p.logger.Debug("Exit IterateRecords", "zone", zone)
+	return nil
+}
+
+// AppendRecords adds records to the zone. It returns the records that were added, in the order of the input.
+// Every record is attempted; if any of them fail, the records that were added are returned together with a
+// *PartialFailureError listing each failed record and its cause.
 func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.init(ctx)
-	slog.Debug("Enter AppendRecords", "zone", zone, "lenRecords", len(records))
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter AppendRecords", "zone", zone, "lenRecords", len(records))
 	domainID, err := p.getDomainIDByZone(ctx, zone)
 	if err != nil {
-		return nil, fmt.Errorf("error getting domain ID for zone %s: %v", zone, err)
+		return nil, fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
 	}
+	zoneRecords := route.toZone(records)
+	added := make([]libdns.Record, len(records))
+	errs := p.runConcurrently(len(records), false, func(i int) error {
+		addedRecord, err := p.createDomainRecord(ctx, zone, domainID, zoneRecords[i])
+		added[i] = addedRecord
+		return err
+	})
 	addedRecords := make([]libdns.Record, 0)
-	for _, record := range records {
-		addedRecord, err := p.createDomainRecord(ctx, zone, domainID, record)
-		if err != nil {
-			if errors.Is(err, ErrUnsupportedType) {
+	failed := make([]RecordError, 0)
+	for i, record := range records {
+		if err := errs[i]; err != nil {
+			if p.SkipUnsupportedTypes && errors.Is(err, ErrUnsupportedType) {
 				// I would rather not fail silently; log at debug level as specified.
-				slog.Debug("skipping unsupported record type", "error", err)
+				p.logger.Debug("skipping unsupported record type", "error", err)
 				continue
 			}
-			slog.Debug("skipping record due to error", "error", err)
+			failed = append(failed, RecordError{Record: record, Err: err})
 			continue
 		}
-		addedRecords = append(addedRecords, addedRecord)
+		addedRecords = append(addedRecords, added[i])
+	}
+	addedRecords = p.presentRecords(route.fromZoneAll(addedRecords))
+	p.logger.Debug("Exit AppendRecords", "zone", zone, "lenAddedRecords", len(addedRecords), "lenFailed", len(failed))
+	if len(failed) > 0 {
+		err := &PartialFailureError{Failed: failed}
+		p.forgetZoneIfGone(zone, err)
+		return addedRecords, err
 	}
-	slog.Debug("Exit AppendRecords", "zone", zone, "lenAddedRecords", len(addedRecords))
 	return addedRecords, nil
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
 // It returns the updated records.
+// If Transactional is set and a change fails, the changes already made are rolled back and the returned error
+// describes both the failure and the outcome of the rollback.
 func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.init(ctx)
-	slog.Debug("Enter SetRecords", "zone", zone, "lenRecords", len(records))
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter SetRecords", "zone", zone, "lenRecords", len(records))
 	domainID, err := p.getDomainIDByZone(ctx, zone)
 	if err != nil {
-		return nil, fmt.Errorf("could not find domain ID for zone: %s: %v", zone, err)
+		return nil, fmt.Errorf("could not find domain ID for zone: %s: %w", zone, err)
 	}
-	setRecords, err := p.createOrUpdateDomainRecords(ctx, zone, domainID, records)
+	setRecords, err := p.createOrUpdateDomainRecords(ctx, zone, domainID, route.toZone(records))
 	if err != nil {
+		p.forgetZoneIfGone(zone, err)
 		return nil, fmt.Errorf("could not create or update domain records: %w", err)
 	}
-	slog.Debug("Exit SetRecords", "zone", zone, "lenSetRecords", len(setRecords))
+	setRecords = p.presentRecords(route.fromZoneAll(setRecords))
+	p.logger.Debug("Exit SetRecords", "zone", zone, "lenSetRecords", len(setRecords))
 	return setRecords, nil
 }
 
-// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
+// DeleteRecords deletes the records from the zone. It returns the records that were deleted, even when some of the
+// deletions fail, in which case the error joins the failures together.
 // As per the libdns interface, any deleted records must match exactly the input record (Name, Type, TTL, Value).
 // If any of (Type, TTL, Value) are "", 0, or "", respectively, deleteDomainRecord will delete any records that match
 // the other fields, regardless of the value of the fields that were left empty.
 // Note: this does not apply to the Name field.
 func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.init(ctx)
-	slog.Debug("Enter DeleteRecords", "zone", zone, "lenRecords", len(records))
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter DeleteRecords", "zone", zone, "lenRecords", len(records))
 	domainID, err := p.getDomainIDByZone(ctx, zone)
 	if err != nil {
-		return nil, fmt.Errorf("error getting domain ID for zone %s: %v", zone, err)
+		return nil, fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
 	}
-	deletedRecords, err := p.deleteDomainRecords(ctx, domainID, records)
+	deletedRecords, err := p.deleteDomainRecords(ctx, domainID, route.toZone(records))
+	deletedRecords = p.presentRecords(route.fromZoneAll(deletedRecords))
 	if err != nil {
-		return nil, fmt.Errorf("error deleting domain records: %w", err)
+		p.forgetZoneIfGone(zone, err)
+		return deletedRecords, fmt.Errorf("error deleting domain records: %w", err)
 	}
-	slog.Debug("Exit DeleteRecords", "zone", zone, "lenDeletedRecords", len(deletedRecords))
+	p.logger.Debug("Exit DeleteRecords", "zone", zone, "lenDeletedRecords", len(deletedRecords))
 	return deletedRecords, nil
 }
 
+// InvalidateZoneCache forgets the cached domain ID of the zone, so that the next call looks it up again. If zone is
+// "", every cached zone is forgotten.
+func (p *Provider) InvalidateZoneCache(zone string) {
+	p.zoneCache.invalidate(zone)
+}
+
 // Interface guards
 var (
 	_ libdns.RecordGetter   = (*Provider)(nil)
@@ -0,0 +1,2136 @@
+package linode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+
+	"github.com/HugoKlepsch/libdns-linode/linodetest"
+)
+
+const testZone = "example.com."
+
+// newFakeAPI starts a fake Linode API that is shut down when the test ends.
+func newFakeAPI(t *testing.T) *linodetest.Server {
+	t.Helper()
+	f := linodetest.NewServer()
+	t.Cleanup(f.Close)
+	return f
+}
+
+// fakeProvider returns a Provider that talks to the fake API.
+func fakeProvider(f *linodetest.Server) *Provider {
+	return &Provider{APIToken: "fake-token", APIURL: f.URL, APIVersion: "v4"}
+}
+
+// rrString renders a record in a compact, comparable form.
+func rrString(record libdns.Record) string {
+	rr := record.RR()
+	return fmt.Sprintf("%s %d %s %s", rr.Name, int(rr.TTL.Seconds()), rr.Type, rr.Data)
+}
+
+// zoneState returns the records stored by the fake for the domain, rendered with rrString and sorted.
+func zoneState(t *testing.T, f *linodetest.Server, domainID int) []string {
+	t.Helper()
+	state := make([]string, 0)
+	for _, record := range f.Records(domainID) {
+		librec, err := convertToLibdns(slog.Default(), &record)
+		if err != nil {
+			t.Fatalf("could not convert fake record %+v: %v", record, err)
+		}
+		state = append(state, rrString(librec))
+	}
+	slices.Sort(state)
+	return state
+}
+
+// seedRecords stores records in the fake the same way the Provider would create them.
+func seedRecords(t *testing.T, f *linodetest.Server, domainID int, records []libdns.Record) {
+	t.Helper()
+	for _, record := range records {
+		opts, err := convertToDomainRecord(slog.Default(), record, testZone)
+		if err != nil {
+			t.Fatalf("convertToDomainRecord returned error: %v", err)
+		}
+		if _, err := f.CreateRecord(domainID, opts); err != nil {
+			t.Fatalf("could not seed record %v: %v", record, err)
+		}
+	}
+}
+
+func TestSetRecords(t *testing.T) {
+	ttl := 5 * time.Minute
+	a := func(name, ip string) libdns.Record {
+		return libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr(ip)}
+	}
+	txt := func(name, text string) libdns.Record {
+		return libdns.TXT{Name: name, TTL: ttl, Text: text}
+	}
+
+	tests := []struct {
+		name     string
+		existing []libdns.Record
+		input    []libdns.Record
+		want     []libdns.Record
+	}{
+		{
+			name:     "example 1: consolidate A records at the root",
+			existing: []libdns.Record{a("@", "192.0.2.1"), a("@", "192.0.2.2"), txt("@", "hello world")},
+			input:    []libdns.Record{a("@", "192.0.2.3")},
+			want:     []libdns.Record{a("@", "192.0.2.3"), txt("@", "hello world")},
+		},
+		{
+			name:     "example 2: other names are untouched",
+			existing: []libdns.Record{a("alpha", "2001:db8::1"), a("alpha", "2001:db8::2"), a("beta", "2001:db8::3"), a("beta", "2001:db8::4")},
+			input:    []libdns.Record{a("alpha", "2001:db8::1"), a("alpha", "2001:db8::2"), a("alpha", "2001:db8::5")},
+			want:     []libdns.Record{a("alpha", "2001:db8::1"), a("alpha", "2001:db8::2"), a("alpha", "2001:db8::5"), a("beta", "2001:db8::3"), a("beta", "2001:db8::4")},
+		},
+		{
+			name:     "A and AAAA for the same name are both replaced",
+			existing: []libdns.Record{a("host", "192.0.2.1"), a("host", "192.0.2.2"), a("host", "2001:db8::1")},
+			input:    []libdns.Record{a("host", "192.0.2.3"), a("host", "2001:db8::2")},
+			want:     []libdns.Record{a("host", "192.0.2.3"), a("host", "2001:db8::2")},
+		},
+		{
+			name:     "AAAA listed before A for the same name",
+			existing: []libdns.Record{a("host", "192.0.2.1"), a("host", "2001:db8::1")},
+			input:    []libdns.Record{a("host", "2001:db8::2"), a("host", "192.0.2.2")},
+			want:     []libdns.Record{a("host", "192.0.2.2"), a("host", "2001:db8::2")},
+		},
+		{
+			name:     "types not in the input are kept",
+			existing: []libdns.Record{a("host", "192.0.2.1"), a("host", "2001:db8::1"), txt("host", "keep me")},
+			input:    []libdns.Record{a("host", "192.0.2.2"), a("host", "2001:db8::2")},
+			want:     []libdns.Record{a("host", "192.0.2.2"), a("host", "2001:db8::2"), txt("host", "keep me")},
+		},
+		{
+			name:     "three types across two names",
+			existing: []libdns.Record{a("one", "192.0.2.1"), a("one", "2001:db8::1"), txt("one", "old"), a("two", "192.0.2.2")},
+			input:    []libdns.Record{a("one", "2001:db8::9"), txt("one", "new"), a("two", "192.0.2.9"), a("two", "2001:db8::8")},
+			want:     []libdns.Record{a("one", "192.0.2.1"), a("one", "2001:db8::9"), txt("one", "new"), a("two", "192.0.2.9"), a("two", "2001:db8::8")},
+		},
+		{
+			name:     "empty zone",
+			existing: nil,
+			input:    []libdns.Record{a("host", "192.0.2.1"), a("host", "2001:db8::1"), txt("host", "hi")},
+			want:     []libdns.Record{a("host", "192.0.2.1"), a("host", "2001:db8::1"), txt("host", "hi")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAPI(t)
+			domainID := f.AddDomain(strings.TrimSuffix(testZone, "."))
+			seedRecords(t, f, domainID, tt.existing)
+			p := fakeProvider(f)
+
+			setRecords, err := p.SetRecords(context.Background(), testZone, tt.input)
+			if err != nil {
+				t.Fatalf("SetRecords returned error: %v", err)
+			}
+
+			gotSet := make([]string, 0, len(setRecords))
+			for _, record := range setRecords {
+				gotSet = append(gotSet, rrString(record))
+			}
+			wantSet := make([]string, 0, len(tt.input))
+			for _, record := range tt.input {
+				wantSet = append(wantSet, rrString(record))
+			}
+			if !slices.Equal(gotSet, wantSet) {
+				t.Errorf("SetRecords returned %v, want %v", gotSet, wantSet)
+			}
+
+			want := make([]string, 0, len(tt.want))
+			for _, record := range tt.want {
+				want = append(want, rrString(record))
+			}
+			slices.Sort(want)
+			if got := zoneState(t, f, domainID); !slices.Equal(got, want) {
+				t.Errorf("zone after SetRecords = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSetRecords_KeepsRecordIDs(t *testing.T) {
+	f := newFakeAPI(t)
+	domainID := f.AddDomain(strings.TrimSuffix(testZone, "."))
+	keptID := f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeA, Name: "www", Target: "192.0.2.1", TTLSec: 300})
+	updatedID := f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeA, Name: "www", Target: "192.0.2.2", TTLSec: 300})
+	p := fakeProvider(f)
+
+	_, err := p.SetRecords(context.Background(), testZone, []libdns.Record{
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.3")},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords returned error: %v", err)
+	}
+
+	for _, record := range f.Records(domainID) {
+		switch record.Target {
+		case "192.0.2.1":
+			if record.ID != keptID {
+				t.Errorf("unchanged record was recreated: ID %d, want %d", record.ID, keptID)
+			}
+		case "192.0.2.3":
+			if record.ID != updatedID {
+				t.Errorf("changed record was not updated in place: ID %d, want %d", record.ID, updatedID)
+			}
+		default:
+			t.Errorf("unexpected record after SetRecords: %+v", record)
+		}
+	}
+}
+
+func TestSetRecords_TransactionalRollback(t *testing.T) {
+	f := newFakeAPI(t)
+	domainID := f.AddDomain(strings.TrimSuffix(testZone, "."))
+	existing := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.2")},
+		libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "old"},
+	}
+	seedRecords(t, f, domainID, existing)
+	before := zoneState(t, f, domainID)
+	p := fakeProvider(f)
+	p.Transactional = true
+
+	// Updates succeed, then the creation of the second TXT record fails
+	f.FailOn(http.StatusBadRequest, func(r *http.Request) bool { return r.Method == http.MethodPost })
+	_, err := p.SetRecords(context.Background(), testZone, []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.3")},
+		libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "new"},
+		libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "newer"},
+	})
+	if err == nil {
+		t.Fatal("expected SetRecords to fail")
+	}
+	if !strings.Contains(err.Error(), "all changes were rolled back") {
+		t.Errorf("expected error to report a successful rollback, got: %v", err)
+	}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, before) {
+		t.Errorf("zone after rollback = %v, want %v", got, before)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("zone not found", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		p := fakeProvider(f)
+		_, err := p.GetRecords(ctx, "example.net.")
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("GetRecords error = %v, want ErrZoneNotFound", err)
+		}
+		_, err = p.AppendRecords(ctx, "example.net.", []libdns.Record{libdns.TXT{Name: "@", Text: "hi"}})
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("AppendRecords error = %v, want ErrZoneNotFound", err)
+		}
+		_, err = p.SetRecords(ctx, "example.net.", []libdns.Record{libdns.TXT{Name: "@", Text: "hi"}})
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("SetRecords error = %v, want ErrZoneNotFound", err)
+		}
+		_, err = p.DeleteRecords(ctx, "example.net.", []libdns.Record{libdns.TXT{Name: "@", Text: "hi"}})
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("DeleteRecords error = %v, want ErrZoneNotFound", err)
+		}
+	})
+
+	t.Run("ambiguous zone", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		f.AddDomain("example.com")
+		_, err := fakeProvider(f).GetRecords(ctx, testZone)
+		if !errors.Is(err, ErrAmbiguousZone) {
+			t.Errorf("GetRecords error = %v, want ErrAmbiguousZone", err)
+		}
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		f.FailOn(http.StatusUnauthorized, func(*http.Request) bool { return true })
+		_, err := fakeProvider(f).GetRecords(ctx, testZone)
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("GetRecords error = %v, want ErrAuthentication", err)
+		}
+		_, err = fakeProvider(f).ListZones(ctx)
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("ListZones error = %v, want ErrAuthentication", err)
+		}
+	})
+}
+
+func TestClassifyAPIError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{err: &linodego.Error{Code: http.StatusUnauthorized}, want: ErrAuthentication},
+		{err: linodego.Error{Code: http.StatusForbidden}, want: ErrAuthentication},
+		{err: &linodego.Error{Code: http.StatusTooManyRequests}, want: ErrRateLimited},
+		{err: fmt.Errorf("wrapped: %w", linodego.Error{Code: http.StatusTooManyRequests}), want: ErrRateLimited},
+		{err: &linodego.Error{Code: http.StatusBadRequest}, want: nil},
+		{err: errors.New("not an API error"), want: nil},
+	}
+	for _, tt := range tests {
+		got := classifyAPIError(tt.err)
+		for _, sentinel := range []error{ErrAuthentication, ErrRateLimited} {
+			if errors.Is(got, sentinel) != (sentinel == tt.want) {
+				t.Errorf("classifyAPIError(%v) = %v, want wrapped %v", tt.err, got, tt.want)
+			}
+		}
+		if !errors.Is(got, tt.err) {
+			t.Errorf("classifyAPIError(%v) = %v, which does not wrap the original error", tt.err, got)
+		}
+	}
+}
+
+func TestAppendRecords_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	txt := libdns.TXT{Name: "_acme-challenge", TTL: 5 * time.Minute, Text: "token"}
+	unsupported := libdns.ServiceBinding{Scheme: "https", Name: "@", TTL: time.Minute, Priority: 1, Target: "svc.example.com."}
+
+	t.Run("unsupported type is reported", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		added, err := fakeProvider(f).AppendRecords(ctx, testZone, []libdns.Record{unsupported, txt})
+		var partialErr *PartialFailureError
+		if !errors.As(err, &partialErr) {
+			t.Fatalf("AppendRecords error = %v, want *PartialFailureError", err)
+		}
+		if len(partialErr.Failed) != 1 || partialErr.Failed[0].Record.RR().Type != "HTTPS" {
+			t.Errorf("failed records = %v, want only the HTTPS record", partialErr.Failed)
+		}
+		if !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("AppendRecords error = %v, want it to wrap ErrUnsupportedType", err)
+		}
+		if len(added) != 1 || rrString(added[0]) != rrString(txt) {
+			t.Errorf("added = %v, want only %v", added, txt)
+		}
+	})
+
+	t.Run("unsupported type is skipped", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		p := fakeProvider(f)
+		p.SkipUnsupportedTypes = true
+		added, err := p.AppendRecords(ctx, testZone, []libdns.Record{unsupported, txt})
+		if err != nil {
+			t.Fatalf("AppendRecords returned error: %v", err)
+		}
+		if len(added) != 1 {
+			t.Errorf("added = %v, want only %v", added, txt)
+		}
+	})
+
+	t.Run("every failure is listed", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		f.FailOn(http.StatusBadRequest, func(r *http.Request) bool { return r.Method == http.MethodPost })
+		p := fakeProvider(f)
+		p.SkipUnsupportedTypes = true
+		records := []libdns.Record{txt, libdns.TXT{Name: "other", Text: "x"}}
+		added, err := p.AppendRecords(ctx, testZone, records)
+		var partialErr *PartialFailureError
+		if !errors.As(err, &partialErr) {
+			t.Fatalf("AppendRecords error = %v, want *PartialFailureError", err)
+		}
+		if len(partialErr.Failed) != len(records) {
+			t.Errorf("got %d failed records, want %d", len(partialErr.Failed), len(records))
+		}
+		if len(added) != 0 {
+			t.Errorf("added = %v, want none", added)
+		}
+	})
+}
+
+func TestLogger(t *testing.T) {
+	f := newFakeAPI(t)
+	f.AddDomain("example.com")
+	defaultLogger := slog.Default()
+
+	var buf strings.Builder
+	p := fakeProvider(f)
+	p.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p.DebugLogsEnabled = true
+	if _, err := p.GetRecords(context.Background(), testZone); err != nil {
+		t.Fatalf("GetRecords returned error: %v", err)
+	}
+
+	if slog.Default() != defaultLogger {
+		t.Error("the provider replaced the default logger")
+	}
+	if !strings.Contains(buf.String(), "Enter GetRecords") {
+		t.Errorf("expected debug logs to go to the provider's Logger; got %q", buf.String())
+	}
+}
+
+func TestPerZoneLocking(t *testing.T) {
+	f := newFakeAPI(t)
+	slowID := f.AddDomain("slow.example")
+	f.AddDomain("fast.example")
+	slowRecords := fmt.Sprintf("/v4/domains/%d/records", slowID)
+
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	f.BeforeRequest(func(r *http.Request) {
+		if r.URL.Path == slowRecords {
+			entered <- struct{}{}
+			<-release
+		}
+	})
+	p := fakeProvider(f)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, zone := range []string{"slow.example.", "slow.example"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.GetRecords(ctx, zone); err != nil {
+				t.Errorf("GetRecords on slow zone returned error: %v", err)
+			}
+		}()
+	}
+	<-entered
+
+	// Another zone is not blocked by the slow one
+	done := make(chan error)
+	go func() {
+		_, err := p.GetRecords(ctx, "fast.example.")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("GetRecords on fast zone returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetRecords on fast zone was blocked by the slow zone")
+	}
+
+	// The second operation on the slow zone waits for the first one, with or without the trailing dot
+	select {
+	case <-entered:
+		t.Error("two operations on the same zone ran concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrentZones(t *testing.T) {
+	f := newFakeAPI(t)
+	zones := []string{"one.example", "two.example", "three.example"}
+	domainIDs := make(map[string]int)
+	for _, zone := range zones {
+		domainIDs[zone] = f.AddDomain(zone)
+	}
+	p := fakeProvider(f)
+	ctx := context.Background()
+
+	const perZone = 10
+	var wg sync.WaitGroup
+	for _, zone := range zones {
+		for i := range perZone {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				record := libdns.TXT{Name: fmt.Sprintf("txt%d", i), TTL: 5 * time.Minute, Text: "hello"}
+				if _, err := p.AppendRecords(ctx, zone+".", []libdns.Record{record}); err != nil {
+					t.Errorf("AppendRecords returned error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				record := libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.AddrFrom4([4]byte{192, 0, 2, byte(i)})}
+				if _, err := p.SetRecords(ctx, zone, []libdns.Record{record}); err != nil {
+					t.Errorf("SetRecords returned error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := p.GetRecords(ctx, zone); err != nil {
+					t.Errorf("GetRecords returned error: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Serialized SetRecords calls leave exactly one www record behind
+	for _, zone := range zones {
+		state := zoneState(t, f, domainIDs[zone])
+		www := 0
+		for _, record := range state {
+			if strings.HasPrefix(record, "www ") {
+				www++
+			}
+		}
+		if len(state) != perZone+1 || www != 1 {
+			t.Errorf("zone %s = %v, want %d TXT records and one www record", zone, state, perZone)
+		}
+	}
+}
+
+// countDomainLookups counts the domain list requests the fake API receives.
+func countDomainLookups(f *linodetest.Server) *atomic.Int32 {
+	var lookups atomic.Int32
+	f.BeforeRequest(func(r *http.Request) {
+		if r.Method == http.MethodGet && strings.TrimSuffix(r.URL.Path, "/") == "/v4/domains" {
+			lookups.Add(1)
+		}
+	})
+	return &lookups
+}
+
+func TestZoneCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cached", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		lookups := countDomainLookups(f)
+		p := fakeProvider(f)
+		for range 3 {
+			if _, err := p.GetRecords(ctx, testZone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		if _, err := p.GetRecords(ctx, "EXAMPLE.com"); err != nil {
+			t.Fatalf("GetRecords returned error: %v", err)
+		}
+		if got := lookups.Load(); got != 1 {
+			t.Errorf("domain lookups = %d, want 1", got)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		lookups := countDomainLookups(f)
+		p := fakeProvider(f)
+		p.ZoneCacheTTL = -1
+		for range 3 {
+			if _, err := p.GetRecords(ctx, testZone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		if got := lookups.Load(); got != 3 {
+			t.Errorf("domain lookups = %d, want 3", got)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		lookups := countDomainLookups(f)
+		p := fakeProvider(f)
+		p.ZoneCacheTTL = time.Millisecond
+		for range 2 {
+			if _, err := p.GetRecords(ctx, testZone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := lookups.Load(); got != 2 {
+			t.Errorf("domain lookups = %d, want 2", got)
+		}
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		f.AddDomain("example.org")
+		lookups := countDomainLookups(f)
+		p := fakeProvider(f)
+		for _, zone := range []string{"example.com.", "example.org."} {
+			if _, err := p.GetRecords(ctx, zone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		p.InvalidateZoneCache("example.com")
+		for _, zone := range []string{"example.com.", "example.org."} {
+			if _, err := p.GetRecords(ctx, zone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		if got := lookups.Load(); got != 3 {
+			t.Errorf("domain lookups after invalidating one zone = %d, want 3", got)
+		}
+		p.InvalidateZoneCache("")
+		for _, zone := range []string{"example.com.", "example.org."} {
+			if _, err := p.GetRecords(ctx, zone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		if got := lookups.Load(); got != 5 {
+			t.Errorf("domain lookups after invalidating all zones = %d, want 5", got)
+		}
+	})
+
+	t.Run("recreated domain", func(t *testing.T) {
+		f := newFakeAPI(t)
+		oldID := f.AddDomain("example.com")
+		p := fakeProvider(f)
+		if _, err := p.GetRecords(ctx, testZone); err != nil {
+			t.Fatalf("GetRecords returned error: %v", err)
+		}
+
+		// The cached domain ID now points at a deleted domain, so the first call fails and drops it
+		f.RemoveDomain(oldID)
+		newID := f.AddDomain("example.com")
+		if _, err := p.GetRecords(ctx, testZone); apiStatusCode(err) != http.StatusNotFound {
+			t.Fatalf("GetRecords on a stale domain ID returned %v, want a 404", err)
+		}
+		record := libdns.TXT{Name: "_acme-challenge", TTL: 5 * time.Minute, Text: "token"}
+		if _, err := p.AppendRecords(ctx, testZone, []libdns.Record{record}); err != nil {
+			t.Fatalf("AppendRecords returned error: %v", err)
+		}
+		if got := zoneState(t, f, newID); len(got) != 1 {
+			t.Errorf("zone state of the recreated domain = %v, want the appended record", got)
+		}
+	})
+}
+
+func TestGenericRecords(t *testing.T) {
+	f := newFakeAPI(t)
+	domainID := f.AddDomain("example.com")
+	f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypePTR, Name: "1", Target: "host.example.com", TTLSec: 300})
+	f.AddRecord(domainID, linodego.DomainRecord{Type: "NEWTYPE", Name: "new", Target: "some data", TTLSec: 300})
+	f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeA, Name: "www", Target: "192.0.2.1", TTLSec: 300})
+	p := fakeProvider(f)
+	ctx := context.Background()
+
+	records, err := p.GetRecords(ctx, testZone)
+	if err != nil {
+		t.Fatalf("GetRecords returned error: %v", err)
+	}
+	got := make([]string, 0, len(records))
+	for _, record := range records {
+		got = append(got, rrString(record))
+	}
+	want := []string{"1 300 PTR host.example.com", "new 300 NEWTYPE some data", "www 300 A 192.0.2.1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetRecords = %v, want %v", got, want)
+	}
+	if _, ok := records[0].(libdns.RR); !ok {
+		t.Errorf("PTR record was returned as %T, want libdns.RR", records[0])
+	}
+
+	// PTR records can be managed through libdns.RR
+	ptr := libdns.RR{Name: "1", TTL: time.Hour, Type: "PTR", Data: "other.example.com"}
+	if _, err := p.SetRecords(ctx, testZone, []libdns.Record{ptr}); err != nil {
+		t.Fatalf("SetRecords returned error: %v", err)
+	}
+	want = []string{"1 3600 PTR other.example.com", "new 300 NEWTYPE some data", "www 300 A 192.0.2.1"}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, want) {
+		t.Errorf("zone after SetRecords = %v, want %v", got, want)
+	}
+	deleted, err := p.DeleteRecords(ctx, testZone, []libdns.Record{libdns.RR{Name: "1", Type: "PTR"}})
+	if err != nil {
+		t.Fatalf("DeleteRecords returned error: %v", err)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("DeleteRecords deleted %v, want the PTR record", deleted)
+	}
+
+	// Other types without a libdns struct are not supported by Linode
+	_, err = p.AppendRecords(ctx, testZone, []libdns.Record{libdns.RR{Name: "x", Type: "NAPTR", Data: "data"}})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("AppendRecords error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestConvertToDomainRecord_ParsesGenericRecords(t *testing.T) {
+	opts, err := convertToDomainRecord(slog.Default(), libdns.RR{Name: "@", TTL: time.Hour, Type: "MX", Data: "10 mail.example.com."}, testZone)
+	if err != nil {
+		t.Fatalf("convertToDomainRecord returned error: %v", err)
+	}
+	if opts.Type != linodego.RecordTypeMX || opts.Name != "" || opts.Priority == nil || *opts.Priority != 10 || opts.Target != "mail.example.com." {
+		t.Errorf("convertToDomainRecord = %+v, want an MX record with preference 10", opts)
+	}
+	if _, err := convertToDomainRecord(slog.Default(), libdns.RR{Name: "www", Type: "A", Data: "not an IP"}, testZone); err == nil {
+		t.Error("expected an invalid A record to be rejected")
+	}
+}
+
+func TestCreateZone_Slave(t *testing.T) {
+	f := newFakeAPI(t)
+	p := fakeProvider(f)
+	ctx := context.Background()
+
+	if _, err := p.CreateZone(ctx, "example.com.", ZoneOptions{Type: linodego.DomainTypeSlave}); err == nil {
+		t.Error("expected a slave zone without master IPs to be rejected")
+	}
+	if _, err := p.CreateZone(ctx, "example.com.", ZoneOptions{Type: linodego.DomainTypeSlave, MasterIPs: []string{"192.0.2.1"}}); err != nil {
+		t.Fatalf("CreateZone returned error: %v", err)
+	}
+	domains := f.Domains()
+	if len(domains) != 1 || domains[0].Type != linodego.DomainTypeSlave || !slices.Equal(domains[0].MasterIPs, []string{"192.0.2.1"}) {
+		t.Fatalf("domains = %+v, want one slave domain transferred from 192.0.2.1", domains)
+	}
+
+	// Clearing a list takes an empty slice; other settings are left alone
+	if err := p.UpdateZone(ctx, "example.com", ZoneOptions{Status: linodego.DomainStatusDisabled, AXFRIPs: []string{}}); err != nil {
+		t.Fatalf("UpdateZone returned error: %v", err)
+	}
+	domain, _ := f.Domain(domains[0].ID)
+	if domain.Status != linodego.DomainStatusDisabled || domain.Type != linodego.DomainTypeSlave || len(domain.MasterIPs) != 1 {
+		t.Errorf("domain after UpdateZone = %+v, want it disabled with the other settings kept", domain)
+	}
+}
+
+func TestListZoneDetails(t *testing.T) {
+	f := newFakeAPI(t)
+	p := fakeProvider(f)
+	ctx := context.Background()
+	zones := []struct {
+		name string
+		opts ZoneOptions
+	}{
+		{name: "primary.example", opts: ZoneOptions{SOAEmail: "hostmaster@primary.example", TTL: time.Hour, Tags: []string{"prod", "web"}}},
+		{name: "secondary.example", opts: ZoneOptions{Type: linodego.DomainTypeSlave, MasterIPs: []string{"192.0.2.1"}, Tags: []string{"prod"}}},
+		{name: "disabled.example", opts: ZoneOptions{SOAEmail: "hostmaster@disabled.example", Status: linodego.DomainStatusDisabled}},
+	}
+	for _, zone := range zones {
+		if _, err := p.CreateZone(ctx, zone.name, zone.opts); err != nil {
+			t.Fatalf("CreateZone(%s) returned error: %v", zone.name, err)
+		}
+	}
+
+	tests := []struct {
+		filter ZoneFilter
+		want   []string
+	}{
+		{filter: ZoneFilter{}, want: []string{"primary.example", "secondary.example", "disabled.example"}},
+		{filter: ZoneFilter{Tags: []string{"prod"}}, want: []string{"primary.example", "secondary.example"}},
+		{filter: ZoneFilter{Tags: []string{"prod", "web"}}, want: []string{"primary.example"}},
+		{filter: ZoneFilter{Type: linodego.DomainTypeSlave}, want: []string{"secondary.example"}},
+		{filter: ZoneFilter{Status: linodego.DomainStatusDisabled}, want: []string{"disabled.example"}},
+		{filter: ZoneFilter{Status: linodego.DomainStatusActive, Type: linodego.DomainTypeSlave, Tags: []string{"web"}}, want: []string{}},
+	}
+	for _, tt := range tests {
+		details, err := p.ListZoneDetails(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("ListZoneDetails(%+v) returned error: %v", tt.filter, err)
+		}
+		got := make([]string, 0, len(details))
+		for _, zone := range details {
+			got = append(got, zone.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ListZoneDetails(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	details, err := p.ListZoneDetails(ctx, ZoneFilter{Tags: []string{"web"}})
+	if err != nil || len(details) != 1 {
+		t.Fatalf("ListZoneDetails = %v, %v, want one zone", details, err)
+	}
+	if zone := details[0]; zone.ID == 0 || zone.Type != linodego.DomainTypeMaster || zone.Status != linodego.DomainStatusActive ||
+		zone.SOAEmail != "hostmaster@primary.example" || zone.TTL != time.Hour {
+		t.Errorf("zone details = %+v, want the settings of primary.example", zone)
+	}
+}
+
+func TestDeleteRecords_FilteredLookup(t *testing.T) {
+	ctx := context.Background()
+	txt := func(name string) libdns.Record {
+		return libdns.TXT{Name: name, TTL: 5 * time.Minute, Text: "token"}
+	}
+	tests := []struct {
+		name       string
+		threshold  int
+		delete     []libdns.Record
+		filtered   int
+		unfiltered int
+	}{
+		{name: "filtered", delete: []libdns.Record{txt("_acme-challenge"), txt("_acme-challenge.www")}, filtered: 2},
+		{name: "duplicate inputs share a lookup", delete: []libdns.Record{txt("one"), txt("one")}, filtered: 1},
+		{name: "apex by type", delete: []libdns.Record{libdns.RR{Name: "@", Type: "TXT"}}, filtered: 1},
+		{name: "above the threshold", threshold: 1, delete: []libdns.Record{txt("one"), txt("two")}, unfiltered: 1},
+		{name: "disabled", threshold: -1, delete: []libdns.Record{txt("one")}, unfiltered: 1},
+		{name: "possible SRV without a type", delete: []libdns.Record{txt("one"), libdns.RR{Name: "_sip._tcp"}}, unfiltered: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAPI(t)
+			domainID := f.AddDomain("example.com")
+			seedRecords(t, f, domainID, []libdns.Record{txt("one"), txt("two"), txt("_acme-challenge"), txt("_acme-challenge.www"), txt("@")})
+			recordsPath := fmt.Sprintf("/v4/domains/%d/records", domainID)
+			var filtered, unfiltered atomic.Int32
+			f.BeforeRequest(func(r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != recordsPath {
+					return
+				}
+				if r.Header.Get("X-Filter") != "" {
+					filtered.Add(1)
+				} else {
+					unfiltered.Add(1)
+				}
+			})
+			p := fakeProvider(f)
+			p.FilteredLookupThreshold = tt.threshold
+
+			deleted, err := p.DeleteRecords(ctx, testZone, tt.delete)
+			if err != nil {
+				t.Fatalf("DeleteRecords returned error: %v", err)
+			}
+			if len(deleted) == 0 {
+				t.Error("DeleteRecords deleted nothing")
+			}
+			if int(filtered.Load()) != tt.filtered || int(unfiltered.Load()) != tt.unfiltered {
+				t.Errorf("got %d filtered and %d unfiltered lookups, want %d and %d", filtered.Load(), unfiltered.Load(), tt.filtered, tt.unfiltered)
+			}
+		})
+	}
+}
+
+func BenchmarkDeleteRecords(b *testing.B) {
+	const zoneSize = 2000
+	for _, bb := range []struct {
+		name      string
+		threshold int
+	}{
+		{name: "filtered", threshold: 0},
+		{name: "full listing", threshold: -1},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			f := linodetest.NewServer()
+			defer f.Close()
+			domainID := f.AddDomain("example.com")
+			for i := range zoneSize {
+				f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeTXT, Name: fmt.Sprintf("txt%d", i), Target: "x", TTLSec: 300})
+			}
+			p := fakeProvider(f)
+			p.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+			p.FilteredLookupThreshold = bb.threshold
+			challenge := linodego.DomainRecord{Type: linodego.RecordTypeTXT, Name: "_acme-challenge", Target: "token", TTLSec: 300}
+			toDelete := []libdns.Record{libdns.TXT{Name: "_acme-challenge", TTL: 5 * time.Minute, Text: "token"}}
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for range b.N {
+				b.StopTimer()
+				f.AddRecord(domainID, challenge)
+				b.StartTimer()
+				if deleted, err := p.DeleteRecords(ctx, testZone, toDelete); err != nil || len(deleted) != 1 {
+					b.Fatalf("DeleteRecords = %v, %v, want the challenge record deleted", deleted, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	const zoneSize = 60
+	newZone := func(t *testing.T) (*linodetest.Server, int, *atomic.Int32) {
+		f := newFakeAPI(t)
+		domainID := f.AddDomain("example.com")
+		for i := range zoneSize {
+			f.AddRecord(domainID, linodego.DomainRecord{Type: linodego.RecordTypeTXT, Name: fmt.Sprintf("txt%d", i), Target: "x", TTLSec: 300})
+		}
+		recordsPath := fmt.Sprintf("/v4/domains/%d/records", domainID)
+		var pages atomic.Int32
+		f.BeforeRequest(func(r *http.Request) {
+			if r.Method == http.MethodGet && r.URL.Path == recordsPath {
+				pages.Add(1)
+			}
+		})
+		return f, domainID, &pages
+	}
+
+	t.Run("every page is read", func(t *testing.T) {
+		f, _, pages := newZone(t)
+		f.SetMaxPageSize(10)
+		records, err := fakeProvider(f).GetRecords(ctx, testZone)
+		if err != nil {
+			t.Fatalf("GetRecords returned error: %v", err)
+		}
+		if len(records) != zoneSize {
+			t.Errorf("GetRecords returned %d records, want %d", len(records), zoneSize)
+		}
+		if got := pages.Load(); got != 6 {
+			t.Errorf("fetched %d pages, want 6", got)
+		}
+	})
+
+	t.Run("page size", func(t *testing.T) {
+		f, _, _ := newZone(t)
+		var pageSizes []string
+		var mu sync.Mutex
+		f.BeforeRequest(func(r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/records") {
+				mu.Lock()
+				pageSizes = append(pageSizes, r.URL.Query().Get("page_size"))
+				mu.Unlock()
+			}
+		})
+		p := fakeProvider(f)
+		p.PageSize = 25
+		records, err := p.GetRecords(ctx, testZone)
+		if err != nil {
+			t.Fatalf("GetRecords returned error: %v", err)
+		}
+		if len(records) != zoneSize {
+			t.Errorf("GetRecords returned %d records, want %d", len(records), zoneSize)
+		}
+		if !slices.Equal(pageSizes, []string{"25", "25", "25"}) {
+			t.Errorf("requested page sizes %v, want three pages of 25", pageSizes)
+		}
+	})
+
+	t.Run("iteration stops early", func(t *testing.T) {
+		f, _, pages := newZone(t)
+		f.SetMaxPageSize(10)
+		errStop := errors.New("stop")
+		seen := 0
+		err := fakeProvider(f).IterateRecords(ctx, testZone, func(libdns.Record) error {
+			seen++
+			if seen == 15 {
+				return errStop
+			}
+			return nil
+		})
+		if err != errStop {
+			t.Errorf("IterateRecords returned %v, want the error returned by the callback", err)
+		}
+		if got := pages.Load(); got != 2 {
+			t.Errorf("fetched %d pages, want 2", got)
+		}
+	})
+
+	t.Run("SetRecords sees records on later pages", func(t *testing.T) {
+		f, domainID, _ := newZone(t)
+		f.SetMaxPageSize(10)
+		record := libdns.TXT{Name: fmt.Sprintf("txt%d", zoneSize-1), TTL: 5 * time.Minute, Text: "y"}
+		if _, err := fakeProvider(f).SetRecords(ctx, testZone, []libdns.Record{record}); err != nil {
+			t.Fatalf("SetRecords returned error: %v", err)
+		}
+		state := zoneState(t, f, domainID)
+		if len(state) != zoneSize || !slices.Contains(state, rrString(record)) {
+			t.Errorf("zone has %d records after SetRecords, want %d including %s", len(state), zoneSize, rrString(record))
+		}
+	})
+
+	t.Run("zones", func(t *testing.T) {
+		f := newFakeAPI(t)
+		for i := range 30 {
+			f.AddDomain(fmt.Sprintf("zone%d.example", i))
+		}
+		f.SetMaxPageSize(10)
+		zones, err := fakeProvider(f).ListZones(ctx)
+		if err != nil {
+			t.Fatalf("ListZones returned error: %v", err)
+		}
+		if len(zones) != 30 {
+			t.Errorf("ListZones returned %d zones, want 30", len(zones))
+		}
+	})
+}
+
+// failTimes returns a request matcher that matches the first n requests for which match returns true.
+func failTimes(n int, match func(r *http.Request) bool) func(r *http.Request) bool {
+	var count atomic.Int32
+	return func(r *http.Request) bool {
+		return match(r) && int(count.Add(1)) <= n
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	isPost := func(r *http.Request) bool { return r.Method == http.MethodPost }
+	isDelete := func(r *http.Request) bool { return r.Method == http.MethodDelete }
+	isRecordList := func(r *http.Request) bool {
+		return r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/records")
+	}
+	fastRetries := RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	newZone := func(t *testing.T) (*linodetest.Server, int, *Provider, *atomic.Int32) {
+		f := newFakeAPI(t)
+		domainID := f.AddDomain("example.com")
+		var requests atomic.Int32
+		f.BeforeRequest(func(*http.Request) { requests.Add(1) })
+		p := fakeProvider(f)
+		p.Retry = fastRetries
+		return f, domainID, p, &requests
+	}
+	challenge := libdns.TXT{Name: "_acme-challenge", TTL: 5 * time.Minute, Text: "token"}
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable} {
+			f, _, p, requests := newZone(t)
+			f.FailOn(status, failTimes(2, isRecordList))
+			if _, err := p.GetRecords(ctx, testZone); err != nil {
+				t.Errorf("GetRecords with two %d responses returned error: %v", status, err)
+			}
+			if got := requests.Load(); got != 4 {
+				t.Errorf("got %d requests with two %d responses, want 4", got, status)
+			}
+		}
+	})
+
+	t.Run("other failures are not retried", func(t *testing.T) {
+		f, _, p, requests := newZone(t)
+		f.FailOn(http.StatusBadRequest, isRecordList)
+		if _, err := p.GetRecords(ctx, testZone); err == nil {
+			t.Error("expected GetRecords to fail")
+		}
+		if got := requests.Load(); got != 2 {
+			t.Errorf("got %d requests, want 2", got)
+		}
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		f, _, p, requests := newZone(t)
+		p.Retry.MaxAttempts = 3
+		f.FailOn(http.StatusTooManyRequests, isRecordList)
+		if _, err := p.GetRecords(ctx, testZone); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("GetRecords error = %v, want ErrRateLimited", err)
+		}
+		if got := requests.Load(); got != 4 {
+			t.Errorf("got %d requests, want 4", got)
+		}
+	})
+
+	t.Run("lost create response", func(t *testing.T) {
+		f, domainID, p, _ := newZone(t)
+		f.LoseResponseOn(http.StatusBadGateway, failTimes(1, isPost))
+		added, err := p.AppendRecords(ctx, testZone, []libdns.Record{challenge})
+		if err != nil {
+			t.Fatalf("AppendRecords returned error: %v", err)
+		}
+		if len(added) != 1 {
+			t.Errorf("AppendRecords returned %v, want the challenge record", added)
+		}
+		if got := zoneState(t, f, domainID); !slices.Equal(got, []string{rrString(challenge)}) {
+			t.Errorf("zone = %v, want the challenge record once", got)
+		}
+	})
+
+	t.Run("rejected create is repeated", func(t *testing.T) {
+		f, domainID, p, _ := newZone(t)
+		f.FailOn(http.StatusBadGateway, failTimes(1, isPost))
+		if _, err := p.AppendRecords(ctx, testZone, []libdns.Record{challenge}); err != nil {
+			t.Fatalf("AppendRecords returned error: %v", err)
+		}
+		if got := zoneState(t, f, domainID); !slices.Equal(got, []string{rrString(challenge)}) {
+			t.Errorf("zone = %v, want the challenge record once", got)
+		}
+	})
+
+	t.Run("lost delete response", func(t *testing.T) {
+		f, domainID, p, _ := newZone(t)
+		seedRecords(t, f, domainID, []libdns.Record{challenge})
+		f.LoseResponseOn(http.StatusGatewayTimeout, failTimes(1, isDelete))
+		deleted, err := p.DeleteRecords(ctx, testZone, []libdns.Record{challenge})
+		if err != nil {
+			t.Fatalf("DeleteRecords returned error: %v", err)
+		}
+		if len(deleted) != 1 || len(f.Records(domainID)) != 0 {
+			t.Errorf("DeleteRecords deleted %v, leaving %v; want the challenge record deleted", deleted, f.Records(domainID))
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		f, _, p, _ := newZone(t)
+		p.Retry = RetryPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+		f.FailOn(http.StatusServiceUnavailable, isRecordList)
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := p.GetRecords(ctx, testZone); err == nil {
+			t.Error("expected GetRecords to fail")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("GetRecords took %v after the context was done", elapsed)
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rp := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 10: time.Second} {
+		for range 20 {
+			if got := rp.backoff(retry); got < want/2 || got > want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", retry, got, want/2, want)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).maxAttempts(); got != DefaultRetryMaxAttempts {
+		t.Errorf("default maxAttempts = %d, want %d", got, DefaultRetryMaxAttempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	withHeader := func(value string) error {
+		return &linodego.Error{Code: http.StatusTooManyRequests, Response: &http.Response{Header: http.Header{"Retry-After": []string{value}}}}
+	}
+	if got := retryAfter(withHeader("7")); got != 7*time.Second {
+		t.Errorf("retryAfter with seconds = %v, want 7s", got)
+	}
+	if got := retryAfter(withHeader(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))); got < 55*time.Second || got > time.Minute {
+		t.Errorf("retryAfter with a date = %v, want about a minute", got)
+	}
+	if got := retryAfter(fmt.Errorf("wrapped: %w", linodego.Error{Code: http.StatusTooManyRequests})); got != 0 {
+		t.Errorf("retryAfter without a response = %v, want 0", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requests are spaced out", func(t *testing.T) {
+		f := newFakeAPI(t)
+		f.AddDomain("example.com")
+		p := fakeProvider(f)
+		p.RateLimit, p.RateLimitBurst = 20, 1
+		p.ZoneCacheTTL = -1
+		start := time.Now()
+		for range 3 {
+			// Two requests each: the domain lookup and the record listing
+			if _, err := p.GetRecords(ctx, testZone); err != nil {
+				t.Fatalf("GetRecords returned error: %v", err)
+			}
+		}
+		// The first request is free, the other five wait 50ms each
+		if elapsed := time.Since(start); elapsed < 240*time.Millisecond {
+			t.Errorf("six requests at 20 per second took %v, want at least 250ms", elapsed)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		f := newFakeAPI(t)
+		p := fakeProvider(f)
+		p.RateLimit, p.RateLimitBurst = 0.001, 1
+		if _, err := p.ListZones(ctx); err != nil {
+			t.Fatalf("first ListZones returned error: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if _, err := p.ListZones(ctx); err == nil {
+			t.Error("expected ListZones to fail while waiting for the rate limit")
+		}
+	})
+
+	t.Run("sharing", func(t *testing.T) {
+		limited := func(token string, share bool) *Provider {
+			p := &Provider{APIToken: token, RateLimit: 5, ShareRateLimit: share}
+			p.init(ctx)
+			return p
+		}
+		token := "shared-token-" + t.Name()
+		if a, b := limited(token, true), limited(token, true); a.limiter != b.limiter {
+			t.Error("Providers sharing a token do not share a rate limiter")
+		}
+		if a, b := limited(token, true), limited(token+"-other", true); a.limiter == b.limiter {
+			t.Error("Providers with different tokens share a rate limiter")
+		}
+		if a, b := limited(token, false), limited(token, false); a.limiter == b.limiter {
+			t.Error("Providers without ShareRateLimit share a rate limiter")
+		}
+		if p := (&Provider{}); p.newLimiter(credentials{}) != nil {
+			t.Error("a Provider without RateLimit has a rate limiter")
+		}
+	})
+
+	t.Run("sharing tokens from the environment", func(t *testing.T) {
+		for _, name := range []string{EnvAPIToken, EnvDNSAPIToken, EnvConfigPath, EnvConfigProfile} {
+			t.Setenv(name, "")
+		}
+		t.Setenv("HOME", t.TempDir())
+		fromEnv := func(token string, rateLimit float64) *Provider {
+			t.Setenv(EnvAPIToken, token)
+			p := &Provider{RateLimit: rateLimit, ShareRateLimit: true}
+			if err := p.init(ctx); err != nil {
+				t.Fatalf("init returned error: %v", err)
+			}
+			return p
+		}
+		token := "env-token-" + t.Name()
+		if a, b := fromEnv(token, 5), fromEnv(token, 5); a.limiter != b.limiter {
+			t.Error("Providers sharing a token from the environment do not share a rate limiter")
+		}
+		a, b := fromEnv(token, 5), fromEnv(token+"-other", 100)
+		if a.limiter == b.limiter {
+			t.Error("Providers with different tokens from the environment share a rate limiter")
+		}
+		if b.limiter.Limit() != 100 {
+			t.Errorf("rate limit = %v, want 100", b.limiter.Limit())
+		}
+
+		profile := func(profile string) *Provider {
+			config := t.TempDir() + "/linode"
+			if err := os.WriteFile(config, []byte("["+profile+"]\ntoken = profile-token\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv(EnvAPIToken, "")
+			t.Setenv(EnvConfigPath, config)
+			p := &Provider{ConfigProfile: profile, RateLimit: 5, ShareRateLimit: true}
+			if err := p.init(ctx); err != nil {
+				t.Fatalf("init returned error: %v", err)
+			}
+			return p
+		}
+		if a, b := profile("one"), profile("two"); a.limiter == b.limiter {
+			t.Error("Providers with different config profiles share a rate limiter")
+		}
+	})
+}
+
+func TestWorkers(t *testing.T) {
+	ctx := context.Background()
+	isWrite := func(r *http.Request) bool { return r.Method != http.MethodGet }
+	// newZone returns a provider with four workers for a zone whose write requests take a while, and the largest
+	// number of write requests seen in flight at once
+	newZone := func(t *testing.T) (*linodetest.Server, int, *Provider, func() int32) {
+		f := newFakeAPI(t)
+		domainID := f.AddDomain("example.com")
+		var inFlight, maxInFlight atomic.Int32
+		f.BeforeRequest(func(r *http.Request) {
+			if !isWrite(r) {
+				return
+			}
+			n := inFlight.Add(1)
+			for {
+				seen := maxInFlight.Load()
+				if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			inFlight.Add(-1)
+		})
+		p := fakeProvider(f)
+		p.Workers = 4
+		return f, domainID, p, maxInFlight.Load
+	}
+	manyRecords := func(n int) []libdns.Record {
+		records := make([]libdns.Record, 0, n)
+		for i := range n {
+			records = append(records, libdns.TXT{Name: fmt.Sprintf("txt%02d", i), TTL: time.Hour, Text: "value"})
+		}
+		return records
+	}
+	rrStrings := func(records []libdns.Record) []string {
+		strs := make([]string, 0, len(records))
+		for _, record := range records {
+			strs = append(strs, rrString(record))
+		}
+		return strs
+	}
+
+	t.Run("AppendRecords", func(t *testing.T) {
+		f, domainID, p, maxInFlight := newZone(t)
+		records := manyRecords(12)
+		added, err := p.AppendRecords(ctx, testZone, records)
+		if err != nil {
+			t.Fatalf("AppendRecords returned error: %v", err)
+		}
+		if got, want := rrStrings(added), rrStrings(records); !slices.Equal(got, want) {
+			t.Errorf("AppendRecords returned %v, want the input order %v", got, want)
+		}
+		if got := len(f.Records(domainID)); got != len(records) {
+			t.Errorf("zone has %d records, want %d", got, len(records))
+		}
+		if got := maxInFlight(); got < 2 || got > 4 {
+			t.Errorf("%d requests were in flight at once, want 2 to 4", got)
+		}
+	})
+
+	t.Run("AppendRecords partial failure", func(t *testing.T) {
+		f, _, p, _ := newZone(t)
+		records := manyRecords(8)
+		f.FailOn(http.StatusBadRequest, func(r *http.Request) bool {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			return r.Method == http.MethodPost && (strings.Contains(string(body), "txt02") || strings.Contains(string(body), "txt05"))
+		})
+		added, err := p.AppendRecords(ctx, testZone, records)
+		var partial *PartialFailureError
+		if !errors.As(err, &partial) {
+			t.Fatalf("AppendRecords error = %v, want a *PartialFailureError", err)
+		}
+		failed := make([]libdns.Record, 0)
+		for _, recordErr := range partial.Failed {
+			failed = append(failed, recordErr.Record)
+		}
+		if got, want := rrStrings(failed), rrStrings([]libdns.Record{records[2], records[5]}); !slices.Equal(got, want) {
+			t.Errorf("failed records = %v, want %v", got, want)
+		}
+		if len(added) != 6 {
+			t.Errorf("AppendRecords added %d records, want 6", len(added))
+		}
+	})
+
+	t.Run("SetRecords and DeleteRecords", func(t *testing.T) {
+		f, domainID, p, maxInFlight := newZone(t)
+		seedRecords(t, f, domainID, manyRecords(10))
+		records := manyRecords(10)
+		for i := range records {
+			records[i] = libdns.TXT{Name: records[i].RR().Name, TTL: time.Hour, Text: "changed"}
+		}
+		set, err := p.SetRecords(ctx, testZone, records)
+		if err != nil {
+			t.Fatalf("SetRecords returned error: %v", err)
+		}
+		if got, want := rrStrings(set), rrStrings(records); !slices.Equal(got, want) {
+			t.Errorf("SetRecords returned %v, want %v", got, want)
+		}
+		deleted, err := p.DeleteRecords(ctx, testZone, records)
+		if err != nil {
+			t.Fatalf("DeleteRecords returned error: %v", err)
+		}
+		if got, want := rrStrings(deleted), rrStrings(records); !slices.Equal(got, want) {
+			t.Errorf("DeleteRecords returned %v, want %v", got, want)
+		}
+		if got := f.Records(domainID); len(got) != 0 {
+			t.Errorf("zone still has %v", got)
+		}
+		if got := maxInFlight(); got < 2 || got > 4 {
+			t.Errorf("%d requests were in flight at once, want 2 to 4", got)
+		}
+	})
+
+	t.Run("DeleteRecords partial failure", func(t *testing.T) {
+		f, domainID, p, _ := newZone(t)
+		records := manyRecords(8)
+		seedRecords(t, f, domainID, records)
+		failing := ""
+		for _, record := range f.Records(domainID) {
+			if record.Name == "txt03" {
+				failing = fmt.Sprintf("/records/%d", record.ID)
+			}
+		}
+		f.FailOn(http.StatusBadRequest, func(r *http.Request) bool {
+			return r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, failing)
+		})
+		deleted, err := p.DeleteRecords(ctx, testZone, records)
+		if err == nil {
+			t.Fatal("DeleteRecords returned no error")
+		}
+		if len(deleted) == 0 || slices.Contains(rrStrings(deleted), rrString(records[3])) {
+			t.Errorf("DeleteRecords returned %v, want the records that were deleted", deleted)
+		}
+		if got := len(f.Records(domainID)); got != len(records)-len(deleted) {
+			t.Errorf("zone has %d records left after %d were reported deleted, want %d", got, len(deleted), len(records)-len(deleted))
+		}
+	})
+
+	t.Run("transactional rollback", func(t *testing.T) {
+		f, domainID, p, _ := newZone(t)
+		p.Transactional = true
+		original := manyRecords(6)
+		seedRecords(t, f, domainID, original)
+		before := zoneState(t, f, domainID)
+		records := manyRecords(12)
+		f.FailOn(http.StatusBadRequest, func(r *http.Request) bool {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			return r.Method == http.MethodPost && strings.Contains(string(body), "txt09")
+		})
+		if _, err := p.SetRecords(ctx, testZone, records); err == nil || !strings.Contains(err.Error(), "rolled back") {
+			t.Fatalf("SetRecords error = %v, want a rolled back failure", err)
+		}
+		if got := zoneState(t, f, domainID); !slices.Equal(got, before) {
+			t.Errorf("zone after rollback = %v, want %v", got, before)
+		}
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	ctx := context.Background()
+	// clearEnv makes the test independent of the credentials of whoever runs it
+	clearEnv := func(t *testing.T) {
+		for _, name := range []string{EnvAPIToken, EnvDNSAPIToken, EnvAPIURL, EnvAPIVersion, EnvConfigPath, EnvConfigProfile} {
+			t.Setenv(name, "")
+		}
+		t.Setenv("HOME", t.TempDir())
+	}
+	// tokenUsed returns the token the provider sends to the fake API
+	tokenUsed := func(t *testing.T, f *linodetest.Server, p *Provider) string {
+		t.Helper()
+		var auth atomic.Value
+		f.BeforeRequest(func(r *http.Request) { auth.Store(r.Header.Get("Authorization")) })
+		if _, err := p.ListZones(ctx); err != nil {
+			t.Fatalf("ListZones returned error: %v", err)
+		}
+		token, _ := auth.Load().(string)
+		return strings.TrimPrefix(token, "Bearer ")
+	}
+	writeFile := func(t *testing.T, content string) string {
+		t.Helper()
+		path := t.TempDir() + "/file"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("no credentials", func(t *testing.T) {
+		clearEnv(t)
+		if _, err := NewProviderFromEnv(); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("NewProviderFromEnv error = %v, want ErrNoCredentials", err)
+		}
+		p := &Provider{}
+		for range 2 {
+			if _, err := p.GetRecords(ctx, testZone); !errors.Is(err, ErrNoCredentials) {
+				t.Errorf("GetRecords error = %v, want ErrNoCredentials", err)
+			}
+		}
+		p = &Provider{APITokenFile: writeFile(t, "\n")}
+		if _, err := p.ListZones(ctx); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("ListZones with an empty token file returned %v, want ErrNoCredentials", err)
+		}
+	})
+
+	t.Run("token file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv(EnvAPIToken, "env-token")
+		f := newFakeAPI(t)
+		p := &Provider{APIURL: f.URL, APIVersion: "v4", APITokenFile: writeFile(t, "file-token\n")}
+		if got := tokenUsed(t, f, p); got != "file-token" {
+			t.Errorf("token = %q, want the token from the file", got)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		clearEnv(t)
+		f := newFakeAPI(t)
+		t.Setenv(EnvDNSAPIToken, "dns-token")
+		t.Setenv(EnvAPIURL, f.URL)
+		t.Setenv(EnvAPIVersion, "v4")
+		p, err := NewProviderFromEnv()
+		if err != nil {
+			t.Fatalf("NewProviderFromEnv returned error: %v", err)
+		}
+		if got := tokenUsed(t, f, p); got != "dns-token" {
+			t.Errorf("token = %q, want %s", got, EnvDNSAPIToken)
+		}
+		t.Setenv(EnvAPIToken, "linode-token")
+		if got := tokenUsed(t, f, &Provider{}); got != "linode-token" {
+			t.Errorf("token = %q, want %s to take precedence", got, EnvAPIToken)
+		}
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		clearEnv(t)
+		f := newFakeAPI(t)
+		t.Setenv(EnvConfigPath, writeFile(t, fmt.Sprintf(
+			"[default]\ntoken = default-token\napi_url = %s\napi_version = v4\n\n[dns]\ntoken = dns-profile-token\n", f.URL)))
+		if got := tokenUsed(t, f, &Provider{}); got != "default-token" {
+			t.Errorf("token = %q, want the default profile", got)
+		}
+		if got := tokenUsed(t, f, &Provider{ConfigProfile: "dns"}); got != "dns-profile-token" {
+			t.Errorf("token = %q, want the dns profile", got)
+		}
+		t.Setenv(EnvConfigProfile, "missing")
+		if _, err := NewProviderFromEnv(); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("NewProviderFromEnv with a missing profile returned %v, want ErrNoCredentials", err)
+		}
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user agent", func(t *testing.T) {
+		f := newFakeAPI(t)
+		var userAgent atomic.Value
+		f.BeforeRequest(func(r *http.Request) { userAgent.Store(r.Header.Get("User-Agent")) })
+		if _, err := fakeProvider(f).ListZones(ctx); err != nil {
+			t.Fatalf("ListZones returned error: %v", err)
+		}
+		if got := userAgent.Load().(string); !strings.HasPrefix(got, "libdns-linode/") || !strings.Contains(got, "linodego/") {
+			t.Errorf("default User-Agent = %q, want it to name libdns-linode and linodego", got)
+		}
+		p := fakeProvider(f)
+		p.UserAgent = "caddy-dns/1.0"
+		if _, err := p.ListZones(ctx); err != nil {
+			t.Fatalf("ListZones returned error: %v", err)
+		}
+		if got := userAgent.Load().(string); got != "caddy-dns/1.0" {
+			t.Errorf("User-Agent = %q, want the configured one", got)
+		}
+	})
+
+	t.Run("client and transport", func(t *testing.T) {
+		f := newFakeAPI(t)
+		var viaClient, viaWrapper atomic.Int32
+		client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			viaClient.Add(1)
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		p := fakeProvider(f)
+		p.HTTPClient = client
+		p.WrapTransport = func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				viaWrapper.Add(1)
+				return next.RoundTrip(r)
+			})
+		}
+		if _, err := p.ListZones(ctx); err != nil {
+			t.Fatalf("ListZones returned error: %v", err)
+		}
+		if viaClient.Load() != 1 || viaWrapper.Load() != 1 {
+			t.Errorf("%d requests went through the client and %d through the wrapper, want 1 each", viaClient.Load(), viaWrapper.Load())
+		}
+		if client.Timeout != 0 {
+			t.Errorf("the caller's client was modified")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		for timeout, want := range map[time.Duration]time.Duration{0: DefaultTimeout, -1: 0, time.Second: time.Second} {
+			p := &Provider{Timeout: timeout}
+			if got := p.httpClient().Timeout; got != want {
+				t.Errorf("timeout with Timeout %v = %v, want %v", timeout, got, want)
+			}
+		}
+		if got := (&Provider{HTTPClient: &http.Client{Timeout: time.Minute}}).httpClient().Timeout; got != time.Minute {
+			t.Errorf("timeout = %v, want the timeout of HTTPClient", got)
+		}
+
+		f := newFakeAPI(t)
+		f.BeforeRequest(func(*http.Request) { time.Sleep(200 * time.Millisecond) })
+		p := fakeProvider(f)
+		p.Timeout = 20 * time.Millisecond
+		p.Retry.MaxAttempts = 1
+		start := time.Now()
+		if _, err := p.ListZones(ctx); err == nil {
+			t.Error("expected ListZones to time out")
+		}
+		if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+			t.Errorf("ListZones took %v with a 20ms timeout", elapsed)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	newZones := func(t *testing.T) (*linodetest.Server, int, int) {
+		f := newFakeAPI(t)
+		return f, f.AddDomain("example.com"), f.AddDomain("example.net")
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		f, exampleCom, _ := newZones(t)
+		report, err := fakeProvider(f).Validate(ctx, testZone, "example.net.")
+		if err != nil {
+			t.Fatalf("Validate returned error: %v", err)
+		}
+		if !report.OK() || report.Username != "linodetest" || report.DomainsAccess != linodego.AccessLevelReadWrite {
+			t.Errorf("report = %+v, want a read-write token without problems", report)
+		}
+		if len(report.Zones) != 2 || report.Zones[0] != (ZoneAccess{Zone: testZone, DomainID: exampleCom, Access: linodego.AccessLevelReadWrite}) {
+			t.Errorf("zones = %+v, want both zones writable", report.Zones)
+		}
+	})
+
+	t.Run("unknown scopes", func(t *testing.T) {
+		f, _, _ := newZones(t)
+		f.SetScopes("")
+		report, err := fakeProvider(f).Validate(ctx, testZone)
+		if err != nil || report.Scopes != nil {
+			t.Errorf("Validate = %+v, %v; want no problems and no scopes", report, err)
+		}
+	})
+
+	t.Run("read-only scope", func(t *testing.T) {
+		f, _, _ := newZones(t)
+		f.SetScopes("linodes:read_write domains:read_only")
+		report, err := fakeProvider(f).Validate(ctx, testZone)
+		if !errors.Is(err, ErrInsufficientPermissions) {
+			t.Errorf("Validate error = %v, want ErrInsufficientPermissions", err)
+		}
+		if report == nil || report.DomainsAccess != linodego.AccessLevelReadOnly || report.Zones[0].Access != linodego.AccessLevelReadOnly {
+			t.Errorf("report = %+v, want read-only access", report)
+		}
+		if report != nil && len(report.Problems) != 2 {
+			t.Errorf("problems = %v, want the scope and the zone", report.Problems)
+		}
+		// The problems are serialized with the report
+		var decoded struct {
+			Problems []string `json:"problems"`
+		}
+		if data, err := json.Marshal(report); err != nil || json.Unmarshal(data, &decoded) != nil ||
+			len(decoded.Problems) != 2 || !strings.Contains(decoded.Problems[0], "domains:read_only") {
+			t.Errorf("report marshaled with problems %q, %v; want the scope and the zone", decoded.Problems, err)
+		}
+	})
+
+	t.Run("restricted user", func(t *testing.T) {
+		f, exampleCom, exampleNet := newZones(t)
+		f.SetGrants(&linodego.UserGrants{Domain: []linodego.GrantedEntity{
+			{ID: exampleCom, Label: "example.com", Permissions: linodego.AccessLevelReadWrite},
+			{ID: exampleNet, Label: "example.net", Permissions: linodego.AccessLevelReadOnly},
+		}})
+		report, err := fakeProvider(f).Validate(ctx, testZone, "example.net")
+		if !errors.Is(err, ErrInsufficientPermissions) || !strings.Contains(err.Error(), "example.net") {
+			t.Errorf("Validate error = %v, want ErrInsufficientPermissions for example.net", err)
+		}
+		if report == nil || !report.Restricted || report.Zones[0].Access != linodego.AccessLevelReadWrite || report.Zones[1].Access != linodego.AccessLevelReadOnly {
+			t.Errorf("report = %+v, want example.com writable and example.net read-only", report)
+		}
+	})
+
+	t.Run("missing zone", func(t *testing.T) {
+		f, _, _ := newZones(t)
+		report, err := fakeProvider(f).Validate(ctx, testZone, "example.org")
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("Validate error = %v, want ErrZoneNotFound", err)
+		}
+		if report == nil || report.Zones[1] != (ZoneAccess{Zone: "example.org"}) {
+			t.Errorf("report = %+v, want example.org without access", report)
+		}
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		f, _, _ := newZones(t)
+		f.RequireToken("another-token")
+		report, err := fakeProvider(f).Validate(ctx, testZone)
+		if !errors.Is(err, ErrAuthentication) || report == nil || report.OK() {
+			t.Errorf("Validate = %+v, %v; want a report with ErrAuthentication", report, err)
+		}
+	})
+}
+
+func TestFindZone(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	f.AddDomain("example.com")
+	f.AddDomain("dev.example.com")
+	f.AddDomain("other.org")
+	domainLists := countDomainLookups(f)
+	p := fakeProvider(f)
+
+	for fqdn, want := range map[string]string{
+		"_acme-challenge.dev.example.com.": "dev.example.com.",
+		"dev.example.com":                  "dev.example.com.",
+		"www.Example.COM.":                 "example.com.",
+		"example.com.":                     "example.com.",
+		"a.b.c.other.org.":                 "other.org.",
+	} {
+		if got, err := p.FindZone(ctx, fqdn); err != nil || got != want {
+			t.Errorf("FindZone(%q) = %q, %v; want %q", fqdn, got, err, want)
+		}
+	}
+	for _, fqdn := range []string{"notexample.com.", "com.", "example.net."} {
+		if got, err := p.FindZone(ctx, fqdn); !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("FindZone(%q) = %q, %v; want ErrZoneNotFound", fqdn, got, err)
+		}
+	}
+
+	before := domainLists.Load()
+	if _, err := p.FindZone(ctx, "www.example.com."); err != nil {
+		t.Fatalf("FindZone returned error: %v", err)
+	}
+	if got := domainLists.Load(); got != before {
+		t.Errorf("cached FindZone listed domains %d more times, want none", got-before)
+	}
+
+	// Names cached as belonging to the parent move to a child zone once it is created, and back once it is deleted
+	if got, err := p.FindZone(ctx, "_acme-challenge.staging.example.com."); err != nil || got != "example.com." {
+		t.Fatalf("FindZone = %q, %v; want example.com.", got, err)
+	}
+	if _, err := p.CreateZone(ctx, "staging.example.com.", ZoneOptions{SOAEmail: "hostmaster@example.com"}); err != nil {
+		t.Fatalf("CreateZone returned error: %v", err)
+	}
+	if got, err := p.FindZone(ctx, "_acme-challenge.staging.example.com."); err != nil || got != "staging.example.com." {
+		t.Errorf("FindZone after CreateZone = %q, %v; want staging.example.com.", got, err)
+	}
+	if err := p.DeleteZone(ctx, "staging.example.com."); err != nil {
+		t.Fatalf("DeleteZone returned error: %v", err)
+	}
+	if got, err := p.FindZone(ctx, "_acme-challenge.staging.example.com."); err != nil || got != "example.com." {
+		t.Errorf("FindZone after DeleteZone = %q, %v; want example.com.", got, err)
+	}
+}
+
+func TestResolveFQDNs(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	exampleCom := f.AddDomain("example.com")
+	devExampleCom := f.AddDomain("dev.example.com")
+	seedRecords(t, f, exampleCom, []libdns.Record{
+		libdns.Address{Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "mail", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.2")},
+	})
+	p := fakeProvider(f)
+	p.ResolveFQDNs = true
+	challenge := libdns.TXT{Name: "_acme-challenge", TTL: 5 * time.Minute, Text: "token"}
+
+	added, err := p.AppendRecords(ctx, "www.example.com.", []libdns.Record{challenge})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	if len(added) != 1 || rrString(added[0]) != rrString(challenge) {
+		t.Errorf("AppendRecords returned %v, want the challenge relative to www.example.com", added)
+	}
+	want := []string{"_acme-challenge.www 300 TXT token", "mail 3600 A 192.0.2.2", "www 3600 A 192.0.2.1"}
+	if got := zoneState(t, f, exampleCom); !slices.Equal(got, want) {
+		t.Errorf("example.com = %v, want %v", got, want)
+	}
+
+	records, err := p.GetRecords(ctx, "WWW.example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords returned error: %v", err)
+	}
+	got := make([]string, 0)
+	for _, record := range records {
+		got = append(got, rrString(record))
+	}
+	slices.Sort(got)
+	if want := []string{"@ 3600 A 192.0.2.1", "_acme-challenge 300 TXT token"}; !slices.Equal(got, want) {
+		t.Errorf("GetRecords(www.example.com.) = %v, want %v", got, want)
+	}
+
+	// The challenge of a name in a delegated zone goes to that zone
+	if _, err := p.SetRecords(ctx, "_acme-challenge.api.dev.example.com.", []libdns.Record{libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "dev"}}); err != nil {
+		t.Fatalf("SetRecords returned error: %v", err)
+	}
+	if got, want := zoneState(t, f, devExampleCom), []string{"_acme-challenge.api 300 TXT dev"}; !slices.Equal(got, want) {
+		t.Errorf("dev.example.com = %v, want %v", got, want)
+	}
+
+	deleted, err := p.DeleteRecords(ctx, "_acme-challenge.www.example.com.", []libdns.Record{libdns.TXT{Name: "@", Text: "token"}})
+	if err != nil {
+		t.Fatalf("DeleteRecords returned error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].RR().Name != "@" {
+		t.Errorf("DeleteRecords returned %v, want the challenge at @", deleted)
+	}
+	if _, err := p.DeleteRecords(ctx, "www.example.com.", []libdns.Record{libdns.TXT{Text: "token"}}); err == nil {
+		t.Error("DeleteRecords accepted a record without a name")
+	}
+	if got := len(f.Records(exampleCom)); got != 2 {
+		t.Errorf("example.com has %d records, want 2", got)
+	}
+
+	p.ResolveFQDNs = false
+	if _, err := p.GetRecords(ctx, "www.example.com."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("GetRecords of a name without ResolveFQDNs returned %v, want ErrZoneNotFound", err)
+	}
+}
+
+func TestInternationalizedNames(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	domainID := f.AddDomain("xn--bcher-kva.example")
+	record := libdns.TXT{Name: "Café", TTL: time.Hour, Text: "hello"}
+
+	p := fakeProvider(f)
+	added, err := p.AppendRecords(ctx, "Bücher.example.", []libdns.Record{record})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	if len(added) != 1 || added[0].RR().Name != "xn--caf-dma" {
+		t.Errorf("AppendRecords returned %v, want the name in ASCII", added)
+	}
+	if got := f.Records(domainID); len(got) != 1 || got[0].Name != "xn--caf-dma" {
+		t.Fatalf("stored records = %+v, want the name in ASCII", got)
+	}
+	recordID := f.Records(domainID)[0].ID
+
+	unicode := fakeProvider(f)
+	unicode.NameForm = NameFormUnicode
+	zones, err := unicode.ListZones(ctx)
+	if err != nil || len(zones) != 1 || zones[0].Name != "bücher.example" {
+		t.Errorf("ListZones = %v, %v; want bücher.example", zones, err)
+	}
+	records, err := unicode.GetRecords(ctx, "xn--bcher-kva.example.")
+	if err != nil || len(records) != 1 || records[0].RR().Name != "café" {
+		t.Errorf("GetRecords = %v, %v; want the name in Unicode", records, err)
+	}
+	if zone, err := unicode.FindZone(ctx, "www.BÜCHER.example."); err != nil || zone != "bücher.example." {
+		t.Errorf("FindZone = %q, %v; want bücher.example.", zone, err)
+	}
+
+	// Names differing only in case and form are the same name
+	set, err := unicode.SetRecords(ctx, "BÜCHER.EXAMPLE", []libdns.Record{libdns.TXT{Name: "CAFÉ", TTL: time.Hour, Text: "hello"}})
+	if err != nil {
+		t.Fatalf("SetRecords returned error: %v", err)
+	}
+	if got := f.Records(domainID); len(got) != 1 || got[0].ID != recordID {
+		t.Errorf("SetRecords left %+v, want record %d kept as-is", got, recordID)
+	}
+	if len(set) != 1 || set[0].RR().Name != "café" {
+		t.Errorf("SetRecords returned %v, want the name in Unicode", set)
+	}
+	deleted, err := p.DeleteRecords(ctx, "bücher.example.", []libdns.Record{libdns.TXT{Name: "CAFÉ"}})
+	if err != nil || len(deleted) != 1 {
+		t.Errorf("DeleteRecords = %v, %v; want the record deleted", deleted, err)
+	}
+}
+
+func TestExportZone(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	f.AddDomain("example.com")
+	p := fakeProvider(f)
+	long := strings.Repeat("a", 300)
+	_, err := p.AppendRecords(ctx, testZone, []libdns.Record{
+		libdns.Address{Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "@", IP: netip.MustParseAddr("2001:db8::1")},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com"},
+		libdns.CNAME{Name: "blog", TTL: time.Hour, Target: "www.example.com"},
+		libdns.TXT{Name: "@", TTL: time.Hour, Text: `v=spf1 "quoted" \ -all`},
+		libdns.TXT{Name: "long", TTL: time.Hour, Text: long},
+		libdns.CAA{Name: "@", TTL: time.Hour, Tag: "iodef", Value: "mailto:ops@example.com"},
+		libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: time.Hour, Priority: 1, Weight: 2, Port: 5060, Target: "sip.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.ExportZone(ctx, testZone, &buf); err != nil {
+		t.Fatalf("ExportZone returned error: %v", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.HasPrefix(line, ";") {
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+	}
+	// The serial is the date of the export, which is not recomputed here in case the day changed since
+	serial := ""
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "@ IN SOA ns1.linode.com. hostmaster.example.com. ("); ok {
+			serial, _, _ = strings.Cut(rest, " ")
+		}
+	}
+	if len(serial) != 10 || !strings.HasSuffix(serial, "00") {
+		t.Errorf("ExportZone wrote SOA serial %q, want a date followed by 00", serial)
+	} else if _, err := time.Parse("20060102", serial[:8]); err != nil {
+		t.Errorf("ExportZone wrote SOA serial %q, want a date followed by 00: %v", serial, err)
+	}
+	want := []string{
+		"$ORIGIN example.com.",
+		"$TTL 86400",
+		"@ IN SOA ns1.linode.com. hostmaster.example.com. (" + serial + " 14400 14400 1209600 86400)",
+		"@ IN NS ns1.linode.com.",
+		"@ IN NS ns2.linode.com.",
+		"@ IN NS ns3.linode.com.",
+		"@ IN NS ns4.linode.com.",
+		"@ IN NS ns5.linode.com.",
+		"@ IN AAAA 2001:db8::1",
+		`@ 3600 IN CAA 0 iodef "mailto:ops@example.com"`,
+		"@ 3600 IN MX 10 mail.example.com.",
+		`@ 3600 IN TXT "v=spf1 \"quoted\" \\ -all"`,
+		"_sip._tcp 3600 IN SRV 1 2 5060 sip.example.com.",
+		"blog 3600 IN CNAME www.example.com.",
+		`long 3600 IN TXT "` + long[:255] + `" "` + long[255:] + `"`,
+		"www 3600 IN A 192.0.2.1",
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("ExportZone wrote\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	if err := p.ExportZone(ctx, "missing.example.", &buf); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("ExportZone of a missing zone returned %v, want ErrZoneNotFound", err)
+	}
+}
+
+func TestParseZoneFile(t *testing.T) {
+	zoneFile := `; comment
+$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.linode.com. hostmaster.example.com. (
+		2024010100 ; serial
+		14400 14400 1209600 86400 )
+@	IN	NS	ns1.linode.com.
+@	IN	NS	ns.other.example.
+@	300	IN	A	192.0.2.1
+	IN	AAAA	2001:db8::1
+www	IN	CNAME	@
+@	IN	MX	10 mail
+@	IN	TXT	"v=spf1 \"quoted\" \\ -all" ; trailing comment
+long	IN	TXT	( "first "
+		"second" )
+semi	IN	TXT	"a;b\059c"
+@	IN	CAA	0 issue "letsencrypt.org"
+_sip._tcp	1d	IN	SRV	1 2 5060 sip.example.com.
+1.2	IN	PTR	host.example.net.
+$ORIGIN sub.example.com.
+host	IN	A	192.0.2.2
+other.example.net.	IN	A	192.0.2.3
+@	CH	TXT	"chaos"
+@	IN	HINFO	"cpu" "os"
+_sip._udp.deep	IN	SRV	1 2 5060 sip
+`
+	records, unsupported, err := ParseZoneFile(strings.NewReader(zoneFile), "Example.com")
+	if err != nil {
+		t.Fatalf("ParseZoneFile returned error: %v", err)
+	}
+	got := make([]string, 0, len(records))
+	for _, record := range records {
+		got = append(got, rrString(record))
+	}
+	want := []string{
+		"@ 3600 NS ns.other.example",
+		"@ 300 A 192.0.2.1",
+		"@ 3600 AAAA 2001:db8::1",
+		"www 3600 CNAME example.com",
+		"@ 3600 MX 10 mail.example.com",
+		`@ 3600 TXT v=spf1 "quoted" \ -all`,
+		"long 3600 TXT first second",
+		"semi 3600 TXT a;b;c",
+		`@ 3600 CAA 0 issue "letsencrypt.org"`,
+		"_sip._tcp 86400 SRV 1 2 5060 sip.example.com",
+		"1.2 3600 PTR host.example.net",
+		"host.sub 3600 A 192.0.2.2",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseZoneFile returned\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	lines := make([]int, 0, len(unsupported))
+	for _, entry := range unsupported {
+		lines = append(lines, entry.Line)
+	}
+	if want := []int{4, 7, 22, 23, 24, 25}; !slices.Equal(lines, want) {
+		t.Errorf("unsupported entries = %v, want lines %v", unsupported, want)
+	}
+	if text := strings.Join(strings.Fields(unsupported[0].Text), " "); text != "@ IN SOA ns1.linode.com. hostmaster.example.com. ( 2024010100 14400 14400 1209600 86400 )" {
+		t.Errorf("SOA entry text = %q", unsupported[0].Text)
+	}
+
+	for _, bad := range []string{
+		"@ IN A 2001:db8::1",
+		"@ IN MX mail",
+		"@ IN TXT \"unterminated",
+		"@ IN A ( 192.0.2.1",
+		"\tIN A 192.0.2.1",
+		"@ IN",
+	} {
+		if _, _, err := ParseZoneFile(strings.NewReader(bad), testZone); err == nil || !strings.HasPrefix(err.Error(), "line 1: ") {
+			t.Errorf("ParseZoneFile(%q) returned %v, want an error for line 1", bad, err)
+		}
+	}
+}
+
+func TestImportZone(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	domainID := f.AddDomain("example.com")
+	p := fakeProvider(f)
+	_, err := p.AppendRecords(ctx, testZone, []libdns.Record{
+		libdns.Address{Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "old", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.9")},
+		libdns.Address{Name: "short", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.5")},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	recordID := func(name string) int {
+		for _, record := range f.Records(domainID) {
+			if record.Name == name {
+				return record.ID
+			}
+		}
+		return 0
+	}
+	shortID := recordID("short")
+	zoneFile := `$TTL 3600
+@	IN	SOA	ns1.linode.com. hostmaster.example.com. 1 2 3 4 5
+www	IN	A	192.0.2.1
+short	IN	A	192.0.2.5
+@	IN	MX	10 mail.example.com.
+_sip._tcp	IN	SRV	1 2 5060 sip.example.com.
+`
+	result, err := p.ImportZone(ctx, testZone, strings.NewReader(zoneFile), ImportAppend)
+	if err != nil {
+		t.Fatalf("ImportZone returned error: %v", err)
+	}
+	if len(result.Created) != 2 || len(result.Updated) != 1 || len(result.Unchanged) != 1 || len(result.Deleted) != 0 || len(result.Unsupported) != 1 {
+		t.Errorf("ImportZone(ImportAppend) returned %+v", result)
+	}
+	want := []string{
+		"@ 3600 MX 10 mail.example.com",
+		"_sip._tcp 3600 SRV 1 2 5060 sip.example.com",
+		"old 3600 A 192.0.2.9",
+		"short 3600 A 192.0.2.5",
+		"www 3600 A 192.0.2.1",
+	}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, want) {
+		t.Errorf("zone after ImportAppend = %v, want %v", got, want)
+	}
+	if got := recordID("short"); got != shortID {
+		t.Errorf("the record with a new TTL has ID %d, want it updated in place as %d", got, shortID)
+	}
+
+	// Importing with ImportReplace updates changed records in place and deletes the record missing from the file
+	wwwID := recordID("www")
+	zoneFile = strings.Replace(zoneFile, "www\tIN", "www\t300\tIN", 1)
+	result, err = p.ImportZone(ctx, testZone, strings.NewReader(zoneFile), ImportReplace)
+	if err != nil {
+		t.Fatalf("ImportZone returned error: %v", err)
+	}
+	if len(result.Created) != 0 || len(result.Updated) != 1 || rrString(result.Updated[0]) != "www 300 A 192.0.2.1" ||
+		len(result.Unchanged) != 3 || len(result.Deleted) != 1 || rrString(result.Deleted[0]) != "old 3600 A 192.0.2.9" {
+		t.Errorf("ImportZone(ImportReplace) returned %+v", result)
+	}
+	want = []string{
+		"@ 3600 MX 10 mail.example.com",
+		"_sip._tcp 3600 SRV 1 2 5060 sip.example.com",
+		"short 3600 A 192.0.2.5",
+		"www 300 A 192.0.2.1",
+	}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, want) {
+		t.Errorf("zone after ImportReplace = %v, want %v", got, want)
+	}
+	if got := recordID("www"); got != wwwID {
+		t.Errorf("the record with a new TTL has ID %d, want it updated in place as %d", got, wwwID)
+	}
+
+	// An exported zone imports back unchanged, including records with the default TTL
+	if _, err := p.AppendRecords(ctx, testZone, []libdns.Record{libdns.TXT{Name: "default", Text: "ttl"}}); err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.ExportZone(ctx, testZone, &buf); err != nil {
+		t.Fatalf("ExportZone returned error: %v", err)
+	}
+	result, err = p.ImportZone(ctx, testZone, &buf, ImportReplace)
+	if err != nil || len(result.Created) != 0 || len(result.Updated) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("ImportZone of the exported zone returned %+v, %v; want no changes", result, err)
+	}
+
+	// TTLs that Linode rounds up compare equal to the rounded ones, so importing the same file twice settles
+	zoneFile = "$TTL 1800\nrounded\tIN\tA\t192.0.2.7\nshort\t600\tIN\tA\t192.0.2.5\n"
+	for i := range 2 {
+		result, err = p.ImportZone(ctx, testZone, strings.NewReader(zoneFile), ImportAppend)
+		if err != nil || len(result.Created) != 1-i || len(result.Updated) != 0 || len(result.Unchanged) != 1+i {
+			t.Errorf("ImportZone #%d of a zone file with rounded TTLs returned %+v, %v", i+1, result, err)
+		}
+	}
+	if got := zoneState(t, f, domainID); !slices.Contains(got, "rounded 3600 A 192.0.2.7") || !slices.Contains(got, "short 3600 A 192.0.2.5") {
+		t.Errorf("zone after importing rounded TTLs = %v", got)
+	}
+
+	if _, err := p.ImportZone(ctx, testZone, strings.NewReader("www IN A bogus\n"), ImportReplace); err == nil {
+		t.Errorf("ImportZone of an invalid zone file returned no error")
+	}
+	if got := zoneState(t, f, domainID); len(got) != 6 {
+		t.Errorf("an invalid zone file changed the zone: %v", got)
+	}
+}
+
+func TestSyncZone(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeAPI(t)
+	domainID := f.AddDomain("example.com")
+	p := fakeProvider(f)
+	_, err := p.AppendRecords(ctx, testZone, []libdns.Record{
+		libdns.Address{Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "old", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.9")},
+		libdns.TXT{Name: "keep", TTL: time.Hour, Text: "same"},
+		libdns.NS{Name: "@", TTL: time.Hour, Target: "ns.other.example"},
+		libdns.TXT{Name: "_acme-challenge", TTL: time.Hour, Text: "token"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	before := zoneState(t, f, domainID)
+	desired := []libdns.Record{
+		libdns.Address{Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.2")},
+		libdns.TXT{Name: "keep", TTL: time.Hour, Text: "same"},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com"},
+		libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: time.Hour, Priority: 1, Weight: 2, Port: 5060, Target: "sip.example.com"},
+		// Linode rounds the TTL up to an hour
+		libdns.TXT{Name: "rounded", TTL: 10 * time.Minute, Text: "up"},
+	}
+	opts := SyncOptions{Protected: []libdns.RR{{Name: "@", Type: "NS"}, {Name: "_acme-challenge"}}}
+
+	plan, err := p.SyncZone(ctx, testZone, desired, opts)
+	if err != nil {
+		t.Fatalf("SyncZone returned error: %v", err)
+	}
+	if plan.Applied || !plan.HasChanges() {
+		t.Errorf("SyncZone without Apply returned Applied = %v, HasChanges = %v", plan.Applied, plan.HasChanges())
+	}
+	if len(plan.Creates) != 3 || rrString(plan.Creates[0]) != "@ 3600 MX 10 mail.example.com" ||
+		rrString(plan.Creates[1]) != "_sip._tcp 3600 SRV 1 2 5060 sip.example.com" || rrString(plan.Creates[2]) != "rounded 3600 TXT up" ||
+		len(plan.Updates) != 1 || rrString(plan.Updates[0].From) != "www 3600 A 192.0.2.1" || rrString(plan.Updates[0].To) != "www 3600 A 192.0.2.2" ||
+		len(plan.Deletes) != 1 || rrString(plan.Deletes[0]) != "old 3600 A 192.0.2.9" ||
+		len(plan.Unchanged) != 1 || len(plan.Protected) != 2 {
+		t.Errorf("SyncZone returned plan %+v", plan)
+	}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, before) {
+		t.Errorf("SyncZone without Apply changed the zone: %v", got)
+	}
+
+	// A reviewed plan is not applied once the zone has changed
+	reviewed := plan
+	if _, err := p.AppendRecords(ctx, testZone, []libdns.Record{libdns.TXT{Name: "unreviewed", TTL: time.Hour, Text: "new"}}); err != nil {
+		t.Fatalf("AppendRecords returned error: %v", err)
+	}
+	opts.Apply = true
+	opts.Reviewed = reviewed
+	plan, err = p.SyncZone(ctx, testZone, desired, opts)
+	if !errors.Is(err, ErrPlanChanged) || plan == nil || plan.Applied || len(plan.Deletes) != 2 {
+		t.Errorf("SyncZone with an outdated reviewed plan returned %+v, %v; want ErrPlanChanged and the new plan", plan, err)
+	}
+	if got := zoneState(t, f, domainID); len(got) != len(before)+1 {
+		t.Errorf("SyncZone with an outdated reviewed plan changed the zone: %v", got)
+	}
+
+	opts.Reviewed = plan
+	plan, err = p.SyncZone(ctx, testZone, desired, opts)
+	if err != nil {
+		t.Fatalf("SyncZone returned error: %v", err)
+	}
+	if !plan.Applied {
+		t.Errorf("SyncZone with Apply returned Applied = false")
+	}
+	want := []string{
+		"@ 3600 MX 10 mail.example.com",
+		"@ 3600 NS ns.other.example",
+		"_acme-challenge 3600 TXT token",
+		"_sip._tcp 3600 SRV 1 2 5060 sip.example.com",
+		"keep 3600 TXT same",
+		"rounded 3600 TXT up",
+		"www 3600 A 192.0.2.2",
+	}
+	if got := zoneState(t, f, domainID); !slices.Equal(got, want) {
+		t.Errorf("zone after SyncZone = %v, want %v", got, want)
+	}
+	// The stored records, SRV ones and those with rounded TTLs included, match the desired ones
+	opts.Reviewed = nil
+	if plan, err := p.SyncZone(ctx, testZone, desired, opts); err != nil || plan.HasChanges() {
+		t.Errorf("second SyncZone returned %+v, %v; want no changes", plan, err)
+	}
+
+	desired = append(desired, libdns.TXT{Name: "_acme-challenge", Text: "other"})
+	if _, err := p.SyncZone(ctx, testZone, desired, opts); err == nil {
+		t.Errorf("SyncZone with a protected desired record returned no error")
+	}
+}
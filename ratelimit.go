@@ -0,0 +1,54 @@
+package linode
+
+import (
+	"context"
+	"crypto/sha256"
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// sharedLimiters holds the rate limiters shared by Providers with ShareRateLimit set, keyed by a hash of the resolved
+// API token, or of the config file profile it comes from, so that every Provider using a token draws from the same
+// budget.
+var sharedLimiters = struct {
+	sync.Mutex
+	byToken map[[sha256.Size]byte]*rate.Limiter
+}{byToken: make(map[[sha256.Size]byte]*rate.Limiter)}
+
+// newLimiter returns the rate limiter of the provider with the given credentials, or nil if requests are not limited.
+func (p *Provider) newLimiter(creds credentials) *rate.Limiter {
+	if p.RateLimit <= 0 {
+		return nil
+	}
+	burst := p.RateLimitBurst
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(p.RateLimit)))
+	}
+	if !p.ShareRateLimit {
+		return rate.NewLimiter(rate.Limit(p.RateLimit), burst)
+	}
+
+	// A profile's token is only known to the client it was loaded into, so the profile stands for it
+	key := sha256.Sum256([]byte("token:" + creds.token))
+	if creds.token == "" {
+		key = sha256.Sum256([]byte("profile:" + creds.configPath + "\x00" + creds.profile))
+	}
+	sharedLimiters.Lock()
+	defer sharedLimiters.Unlock()
+	limiter, ok := sharedLimiters.byToken[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(p.RateLimit), burst)
+		sharedLimiters.byToken[key] = limiter
+	}
+	return limiter
+}
+
+// waitForRateLimit blocks until the provider's rate limit allows another request, or the context is done.
+func (p *Provider) waitForRateLimit(ctx context.Context) error {
+	if p.limiter == nil {
+		return nil
+	}
+	return p.limiter.Wait(ctx)
+}
@@ -0,0 +1,148 @@
+package linode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/linode/linodego"
+)
+
+// Defaults for the fields of RetryPolicy that are left zero.
+const (
+	DefaultRetryMaxAttempts    = 5
+	DefaultRetryInitialBackoff = time.Second
+	DefaultRetryMaxBackoff     = 30 * time.Second
+)
+
+// RetryPolicy controls how Linode API requests are retried. A request is retried when Linode rate limits it (429),
+// when Linode has a transient problem (408, 500, 502, 503 or 504) and when no response was received at all.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per request, including the first one. Zero means
+	// DefaultRetryMaxAttempts and 1 disables retries.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// InitialBackoff is the wait before the first retry. It doubles with every further retry, and a random jitter of
+	// up to half the wait is subtracted so that clients do not retry in lockstep. Zero means
+	// DefaultRetryInitialBackoff.
+	InitialBackoff time.Duration `json:"initial_backoff,omitempty"`
+	// MaxBackoff caps the exponential backoff. Zero means DefaultRetryMaxBackoff. A wait requested by Linode with a
+	// Retry-After header is honoured even when it is longer.
+	MaxBackoff time.Duration `json:"max_backoff,omitempty"`
+}
+
+func (rp RetryPolicy) maxAttempts() int {
+	if rp.MaxAttempts <= 0 {
+		return DefaultRetryMaxAttempts
+	}
+	return rp.MaxAttempts
+}
+
+// backoff returns the wait before the given retry, counting from 1.
+func (rp RetryPolicy) backoff(retry int) time.Duration {
+	initial, maxBackoff := rp.InitialBackoff, rp.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultRetryInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+	wait := initial
+	for i := 1; i < retry && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, maxBackoff)
+	return wait - rand.N(wait/2+1)
+}
+
+// retryable reports whether a failed request may be retried, and whether it is ambiguous, i.e. it may have taken
+// effect on Linode even though it failed.
+func retryable(err error) (retry, ambiguous bool) {
+	switch apiStatusCode(err) {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout:
+		return true, false
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, true
+	case linodego.ErrorFromError:
+		// The request failed in the client, e.g. the connection was reset, so the response may have been lost
+		return true, true
+	}
+	return false, false
+}
+
+// retryAfter returns the wait requested by the Retry-After header of the API error's response, or 0 if there is none.
+func retryAfter(err error) time.Duration {
+	var response *http.Response
+	var ptrErr *linodego.Error
+	var valErr linodego.Error
+	switch {
+	case errors.As(err, &ptrErr):
+		response = ptrErr.Response
+	case errors.As(err, &valErr):
+		response = valErr.Response
+	}
+	if response == nil {
+		return 0
+	}
+	header := response.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		return max(0, time.Until(when))
+	}
+	return 0
+}
+
+// withRetry calls do until it succeeds, fails with an error that is not retryable, or runs out of attempts, and
+// returns the result of the last call. Every call waits for the provider's rate limit first. After an ambiguous
+// failure, and before trying again, it calls tookEffect, if not nil, to find out whether the failed call took effect
+// after all. If so, the result of tookEffect is returned instead of repeating a call that is not idempotent; if that
+// cannot be determined, the failure is returned.
+func withRetry[T any](ctx context.Context, p *Provider, op string, tookEffect func(context.Context) (T, bool, error), do func(context.Context) (T, error)) (T, error) {
+	attempts := p.Retry.maxAttempts()
+	var result T
+	var err error
+	for attempt := 1; ; attempt++ {
+		if waitErr := p.waitForRateLimit(ctx); waitErr != nil {
+			if err != nil {
+				// Report the failure being retried rather than the context running out while waiting
+				return result, err
+			}
+			return result, fmt.Errorf("waiting for rate limit: %w", waitErr)
+		}
+		result, err = do(ctx)
+		if err == nil {
+			return result, nil
+		}
+		retry, ambiguous := retryable(err)
+		if !retry || attempt >= attempts || ctx.Err() != nil {
+			return result, err
+		}
+
+		wait := max(p.Retry.backoff(attempt), retryAfter(err))
+		p.logger.Debug("retrying Linode API request", "op", op, "attempt", attempt, "wait", wait, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+
+		if ambiguous && tookEffect != nil {
+			effect, ok, checkErr := tookEffect(ctx)
+			if checkErr != nil {
+				p.logger.Debug("could not check whether a failed request took effect", "op", op, "error", checkErr)
+				return result, err
+			}
+			if ok {
+				p.logger.Debug("failed request took effect after all", "op", op, "attempt", attempt)
+				return effect, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+)
+
+// FindZone returns the most specific zone that contains the fully qualified name, with a trailing dot. For example,
+// it returns "dev.example.com." for "_acme-challenge.dev.example.com." when both example.com and dev.example.com are
+// Linode domains, and "example.com." for "www.example.com.". If no zone contains the name, the error wraps
+// ErrZoneNotFound. Results are cached like domain IDs, see ZoneCacheTTL.
+func (p *Provider) FindZone(ctx context.Context, fqdn string) (string, error) {
+	if err := p.init(ctx); err != nil {
+		return "", err
+	}
+	p.logger.Debug("Enter FindZone", "fqdn", fqdn)
+	name := normalizeZone(fqdn)
+	ttl := p.zoneCacheTTL()
+	if ttl > 0 {
+		if zone, ok := p.zoneCache.getResolved(name); ok {
+			p.logger.Debug("Exit FindZone", "fqdn", fqdn, "zone", zone, "cached", true)
+			return p.presentName(zone) + ".", nil
+		}
+	}
+	domains, err := p.listAllDomains(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("error listing domains: %w", err)
+	}
+	var best *linodego.Domain
+	bestZone := ""
+	for i, domain := range domains {
+		zone := normalizeZone(domain.Domain)
+		if name != zone && !strings.HasSuffix(name, "."+zone) {
+			continue
+		}
+		if len(zone) > len(bestZone) {
+			best, bestZone = &domains[i], zone
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no zone contains %s: %w", fqdn, ErrZoneNotFound)
+	}
+	if ttl > 0 {
+		p.zoneCache.put(bestZone, best.ID, ttl)
+		p.zoneCache.putResolved(name, bestZone, ttl)
+	}
+	p.logger.Debug("Exit FindZone", "fqdn", fqdn, "zone", bestZone, "domainID", best.ID)
+	return p.presentName(bestZone) + ".", nil
+}
+
+// zoneRoute relates the zone argument of a record method to the zone holding the records. They only differ when
+// ResolveFQDNs is set and the argument is a name inside a zone, such as "dev.example.com." in zone "example.com.",
+// in which case record names are rewritten between the two. Both are normalized and end with a dot.
+type zoneRoute struct {
+	// name is the zone argument, which the caller's record names are relative to.
+	name string
+	// zone is the zone holding the records.
+	zone string
+}
+
+// routeZone returns the route for the zone argument of a record method.
+func (p *Provider) routeZone(ctx context.Context, name string) (zoneRoute, error) {
+	if !p.ResolveFQDNs {
+		return zoneRoute{name: name, zone: name}, nil
+	}
+	zone, err := p.FindZone(ctx, name)
+	if err != nil {
+		return zoneRoute{}, err
+	}
+	route := zoneRoute{name: normalizeZone(name) + ".", zone: normalizeZone(zone) + "."}
+	if route.name != route.zone {
+		p.logger.Debug("routing records to their zone", "name", route.name, "zone", route.zone)
+	}
+	return route, nil
+}
+
+// toZone rewrites records with names relative to r.name into records with names relative to r.zone.
+func (r zoneRoute) toZone(records []libdns.Record) []libdns.Record {
+	if r.name == r.zone {
+		return records
+	}
+	rewritten := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		rr := record.RR()
+		if rr.Name == "" {
+			// Leave invalid names for the record methods to reject rather than turning them into r.name
+			rewritten = append(rewritten, record)
+			continue
+		}
+		rewritten = append(rewritten, renameRecord(record, libdns.RelativeName(libdns.AbsoluteName(rr.Name, r.name), r.zone)))
+	}
+	return rewritten
+}
+
+// fromZone rewrites a record with a name relative to r.zone into one with a name relative to r.name. It returns
+// false if the record is not at or below r.name, so that its name cannot be made relative to it.
+func (r zoneRoute) fromZone(record libdns.Record) (libdns.Record, bool) {
+	if r.name == r.zone {
+		return record, true
+	}
+	fqdn := libdns.AbsoluteName(record.RR().Name, r.zone)
+	// r.name is normalized, so compare case-insensitively but keep the case of the record name
+	lower := strings.ToLower(fqdn)
+	if lower == r.name {
+		return renameRecord(record, "@"), true
+	}
+	if !strings.HasSuffix(lower, "."+r.name) {
+		return nil, false
+	}
+	return renameRecord(record, fqdn[:len(fqdn)-len(r.name)-1]), true
+}
+
+// fromZoneAll rewrites records with fromZone. Every record must be at or below r.name.
+func (r zoneRoute) fromZoneAll(records []libdns.Record) []libdns.Record {
+	if r.name == r.zone {
+		return records
+	}
+	rewritten := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		if renamed, ok := r.fromZone(record); ok {
+			rewritten = append(rewritten, renamed)
+		}
+	}
+	return rewritten
+}
+
+// renameRecord returns the record with the given name, keeping its type where possible.
+func renameRecord(record libdns.Record, name string) libdns.Record {
+	rr := record.RR()
+	rr.Name = name
+	parsed, err := rr.Parse()
+	if err != nil {
+		return rr
+	}
+	return parsed
+}
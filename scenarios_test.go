@@ -0,0 +1,729 @@
+package linode
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+)
+
+// A scenario exercises a Provider end to end. The client talks to the same API as the provider and is used to set up
+// and inspect domains behind the provider's back. Scenarios run against the fake API in TestScenarios and against
+// Linode in TestIntegration.
+type scenario struct {
+	name string
+	run  func(t *testing.T, p *Provider, c linodego.Client)
+}
+
+var scenarios = []scenario{
+	{name: "ListZones", run: scenarioListZones},
+	{name: "GetRecords", run: scenarioGetRecords},
+	{name: "DeleteRecords", run: scenarioDeleteRecords},
+	{name: "AppendRecords", run: scenarioAppendRecords},
+	{name: "SetRecords_Example1", run: scenarioSetRecords_Example1},
+	{name: "SetRecords_Example2", run: scenarioSetRecords_Example2},
+	{name: "SetRecords_KeepsRecordIDs", run: scenarioSetRecords_KeepsRecordIDs},
+	{name: "SetRecords_TransactionalRollback", run: scenarioSetRecords_TransactionalRollback},
+	{name: "AcmeChallenge_AppendTXT", run: scenarioAcmeChallenge_AppendTXT},
+	{name: "ZoneLifecycle", run: scenarioZoneLifecycle},
+}
+
+func TestScenarios(t *testing.T) {
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			f := newFakeAPI(t)
+			c := linodego.NewClient(f.Client())
+			c.SetToken("fake-token")
+			c.SetBaseURL(f.URL)
+			c.SetAPIVersion("v4")
+			sc.run(t, fakeProvider(f), c)
+		})
+	}
+}
+
+// randHex returns n random bytes hex-encoded.
+func randHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// createDomainRecordOrDie creates a domain record and fails the test if there's an error.
+func createDomainRecordOrDie(t *testing.T, c linodego.Client, domainID int, opts linodego.DomainRecordCreateOptions) {
+	t.Helper()
+	if _, err := c.CreateDomainRecord(context.Background(), domainID, opts); err != nil {
+		t.Fatalf("failed to create domain record (type=%s name=%s): %v", string(opts.Type), opts.Name, err)
+	}
+}
+
+// createDomainRecordsOrDie creates a domain record and fails the test if there's an error.
+func createDomainRecordsOrDie(t *testing.T, c linodego.Client, zone string, domainID int, records []libdns.Record) {
+	t.Helper()
+	for _, record := range records {
+		createOpts, err := convertToDomainRecord(slog.Default(), record, zone)
+		if err != nil {
+			t.Fatalf("convertToDomainRecord returned error: %v", err)
+		}
+		createDomainRecordOrDie(t, c, domainID, createOpts)
+	}
+}
+
+func makeTestDomainRecords(domain string) []libdns.Record {
+	domain = strings.TrimSuffix(domain, ".")
+	testDomains := []libdns.Record{
+		// Add a diverse set of sample records within this zone.
+		// A records
+		libdns.Address{Name: "a1", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "a2", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.2")},
+		libdns.Address{Name: "dup", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.10")},
+		libdns.Address{Name: "dup", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.11")},
+		libdns.Address{Name: "*", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.99")},
+		// AAAA records
+		libdns.Address{Name: "aaaa1", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::1")},
+		libdns.Address{Name: "aaaa2", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::2")},
+		libdns.Address{Name: "dup6", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::10")},
+		libdns.Address{Name: "dup6", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::11")},
+		libdns.Address{Name: "*.wld", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::99")},
+		// TXT records (subdomain and root)
+		libdns.TXT{Name: "txt1", TTL: 300 * time.Second, Text: "hello-libdns"},
+		libdns.TXT{Name: "@", TTL: 300 * time.Second, Text: "root-text"},
+		// CNAME record
+		libdns.CNAME{Name: "www", TTL: 300 * time.Second, Target: fmt.Sprintf("a1.%s", domain)},
+		// MX records
+		libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: fmt.Sprintf("mail.%s", domain)},
+		// SRV records (common types)
+		// _sip._tcp -> sipserver
+		libdns.SRV{Name: "@", TTL: 300 * time.Second, Service: "sip", Transport: "tcp", Priority: 10, Weight: 5, Port: 5060, Target: fmt.Sprintf("sipserver.%s", domain)},
+		// _xmpp-client._tcp -> xmpp
+		libdns.SRV{Name: "@", TTL: 300 * time.Second, Service: "xmpp-client", Transport: "tcp", Priority: 20, Weight: 10, Port: 5222, Target: fmt.Sprintf("xmpp.%s", domain)},
+		// CAA records for letsencrypt.org
+		libdns.CAA{Name: "@", TTL: 300 * time.Second, Flags: 0, Tag: "iodef", Value: fmt.Sprintf("mailto:security@%s", domain)},
+		libdns.CAA{Name: "letsencrypt", TTL: 300 * time.Second, Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+		libdns.CAA{Name: "letsencryptwild", TTL: 300 * time.Second, Flags: 0, Tag: "issuewild", Value: "letsencrypt.org"},
+	}
+	return testDomains
+}
+
+// makeTestDomain creates a temporary domain and sample records, and registers cleanup to delete it.
+// It returns the domain name and ID.
+func makeTestDomain(t *testing.T, c linodego.Client) (string, int) {
+	t.Helper()
+	ctx := context.Background()
+
+	suffix := time.Now().UTC().Format("20060102-150405") + "-" + randHex(4)
+	domain := fmt.Sprintf("libdns-test-%s.example", suffix)
+
+	// Create master domain; SOAEmail is required by Linode for master domains.
+	d, err := c.CreateDomain(ctx, linodego.DomainCreateOptions{
+		Domain:   domain,
+		Type:     linodego.DomainTypeMaster,
+		SOAEmail: "hostmaster@" + domain,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test domain %q: %v", domain, err)
+	}
+
+	// Ensure cleanup deletes the domain.
+	t.Cleanup(func() {
+		_ = c.DeleteDomain(context.Background(), d.ID)
+	})
+
+	return domain, d.ID
+}
+
+func assertPresent(t *testing.T, expected libdns.Record, haystack []libdns.Record) {
+	t.Helper()
+	exp := expected.RR()
+	for _, actual := range haystack {
+		act := actual.RR()
+		if exp.Name == act.Name && exp.Type == act.Type && exp.TTL == act.TTL && exp.Data == act.Data {
+			return
+		}
+	}
+	t.Errorf("expected record not found in haystack: %+v", exp)
+}
+
+func assertAbsent(t *testing.T, expected libdns.Record, haystack []libdns.Record) {
+	t.Helper()
+	exp := expected.RR()
+	for _, actual := range haystack {
+		act := actual.RR()
+		if exp.Name == act.Name && exp.Type == act.Type && exp.TTL == act.TTL && exp.Data == act.Data {
+			t.Errorf("unexpected record found in haystack: %+v", exp)
+		}
+	}
+}
+
+func scenarioListZones(t *testing.T, p *Provider, c linodego.Client) {
+
+	// Create a fresh domain for this test case.
+	zone, domainID := makeTestDomain(t, c)
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+
+	zones, err := p.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones returned error: %v", err)
+	}
+
+	found := false
+	for _, z := range zones {
+		if libdns.AbsoluteName("@", z.Name) == libdns.AbsoluteName("@", zone) || z.Name == zone {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatalf("expected to find test zone %q in ListZones results", zone)
+	}
+	t.Logf("ListZones succeeded; found test zone %q among %d zones", zone, len(zones))
+}
+
+func scenarioGetRecords(t *testing.T, p *Provider, c linodego.Client) {
+
+	testForZone := func(t *testing.T, zone string) {
+		t.Helper()
+		records, err := p.GetRecords(context.Background(), zone)
+		if err != nil {
+			t.Fatalf("GetRecords returned error for zone %q: %v", zone, err)
+		}
+
+		// Assert that our sample records are present.
+		expectedRecords := makeTestDomainRecords(zone)
+		seenRecords := make([]bool, len(records))
+		for _, expected := range expectedRecords {
+			t.Run(expected.RR().Data, func(t *testing.T) {
+				exp := expected.RR()
+				found := false
+				for recI, actual := range records {
+					act := actual.RR()
+					if exp.Name == act.Name && exp.Type == act.Type && exp.TTL == act.TTL && exp.Data == act.Data {
+						if seenRecords[recI] {
+							t.Errorf("matched record with two expected records: record (%+v) in GetRecords results for zone %q", expected, zone)
+						}
+						found = true
+						seenRecords[recI] = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("expected to find record %+v in GetRecords results for zone %q", expected, zone)
+				}
+			})
+		}
+		// Assert that all no extra records were returned.
+		for recI, seen := range seenRecords {
+			if !seen {
+				t.Errorf("record in GetRecords results not in expectedResults for zone %q: (%+v)", zone, records[recI])
+			}
+		}
+		t.Logf("GetRecords succeeded for zone %q; found expected sample records", zone)
+	}
+
+	// Domain without dot suffix
+	zone, domainID := makeTestDomain(t, c)
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone) })
+
+	// Domain with dot suffix
+	zone, domainID = makeTestDomain(t, c)
+	zone += "."
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone) })
+}
+
+func scenarioDeleteRecords(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+
+	testForZone := func(t *testing.T, zone string) {
+		// Build a set of deletions to exercise exact and wildcard semantics.
+		toDelete := []libdns.Record{
+			// Exact match delete: A a1 192.0.2.1 with TTL 300
+			libdns.RR{Name: "a1", Type: "A", TTL: 300 * time.Second, Data: netip.MustParseAddr("192.0.2.1").String()},
+
+			// Wildcard type/TTL/value: delete all records with name "dup"
+			// (in our test data, these are two A records with different IPs)
+			libdns.RR{Name: "dup"},
+
+			// TTL wildcard for specific TXT record: delete txt1 (any TTL)
+			libdns.RR{Name: "txt1", Type: "TXT", TTL: 0, Data: ""},
+
+			// Delete the MX at zone root (name "@"), with wildcard TTL
+			libdns.RR{Name: "@", Type: "MX"},
+		}
+
+		deleted, err := p.DeleteRecords(ctx, zone, toDelete)
+		if err != nil {
+			t.Fatalf("DeleteRecords returned error for zone %q: %v", zone, err)
+		}
+
+		// Collect deleted records by name+type for validation convenience.
+		deletedMap := make(map[string][]libdns.RR)
+		for _, rec := range deleted {
+			rr := rec.RR()
+			key := rr.Name + "|" + rr.Type
+			deletedMap[key] = append(deletedMap[key], rr)
+		}
+
+		// We expect at least 5 records deleted:
+		// - a1 A (exact) -> 1
+		// - dup A (name wildcard) -> 2
+		// - txt1 TXT -> 1
+		// - @ MX -> 1
+		if len(deleted) < 5 {
+			t.Fatalf("expected at least 5 records to be deleted, got %d (deleted=%v)", len(deleted), deleted)
+		}
+
+		// Verify the expected specific deletions exist in the returned slice.
+		// a1 A 192.0.2.1
+		{
+			key := "a1|A"
+			found := false
+			for _, rr := range deletedMap[key] {
+				if rr.TTL == 300*time.Second && rr.Data == netip.MustParseAddr("192.0.2.1").String() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected exact deletion of A a1 192.0.2.1 TTL 300s")
+			}
+		}
+		// dup A should have 2 deletions regardless of IPs/TTLs when using wildcard input.
+		if cnt := len(deletedMap["dup|A"]); cnt != 2 {
+			t.Errorf("expected to delete 2 A records for name 'dup'; got %d", cnt)
+		}
+		// txt1 TXT should be deleted
+		if len(deletedMap["txt1|TXT"]) != 1 {
+			t.Errorf("expected to delete TXT record 'txt1'")
+		}
+		// root MX should be deleted (root is represented by '@')
+		if len(deletedMap["@|MX"]) != 1 {
+			t.Errorf("expected to delete MX record at root '@'")
+		}
+
+		// Now confirm via GetRecords that the deleted records are indeed gone,
+		// and that unrelated records still exist.
+		after, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			t.Fatalf("GetRecords after deletions returned error for zone %q: %v", zone, err)
+		}
+
+		// Helper to assert absence of a record in the current zone.
+		assertAbsentWithWildcard := func(expected libdns.Record) {
+			exp := expected.RR()
+			for _, actual := range after {
+				act := actual.RR()
+				if exp.Name == act.Name && exp.Type == act.Type && (exp.TTL == 0 || exp.TTL == act.TTL) && (exp.Data == "" || exp.Data == act.Data) {
+					t.Errorf("record should have been deleted but still present: %+v", exp)
+					return
+				}
+			}
+		}
+
+		// Assert absence of those we intended to delete.
+		assertAbsentWithWildcard(libdns.RR{Name: "a1", Type: "A", TTL: 300 * time.Second, Data: netip.MustParseAddr("192.0.2.1").String()})
+		// For wildcard name-only deletions, ensure that no records with that name remain for type A.
+		assertAbsentWithWildcard(libdns.RR{Name: "dup"})
+		assertAbsentWithWildcard(libdns.RR{Name: "txt1", Type: "TXT"})
+		assertAbsentWithWildcard(libdns.RR{Name: "@", Type: "MX"})
+
+		// Sanity checks: unrelated records should still exist.
+		// a2 A should remain
+		assertPresent(t, libdns.Address{Name: "a2", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.2")}, after)
+		// Root TXT should remain
+		assertPresent(t, libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "root-text"}, after)
+
+		t.Logf("DeleteRecords succeeded for zone %q; expected records were deleted and unrelated records remain", zone)
+	}
+
+	// Domain without dot suffix
+	zone, domainID := makeTestDomain(t, c)
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone) })
+
+	// Domain with dot suffix
+	zone, domainID = makeTestDomain(t, c)
+	zone += "."
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone) })
+}
+
+func scenarioAppendRecords(t *testing.T, p *Provider, c linodego.Client) {
+	p.SkipUnsupportedTypes = true
+	ctx := context.Background()
+
+	zone, domainID := makeTestDomain(t, c)
+	createDomainRecordsOrDie(t, c, zone, domainID, makeTestDomainRecords(zone))
+
+	// Prepare a variety of records to append.
+	newA := libdns.Address{Name: "newa", TTL: 2 * time.Minute, IP: netip.MustParseAddr("192.0.2.200")}
+	newAAAA := libdns.Address{Name: "newaaaa", TTL: 5 * time.Minute, IP: netip.MustParseAddr("2001:db8::200")}
+	newTXT := libdns.TXT{Name: "addtxt", TTL: 2 * time.Minute, Text: "hello-append"}
+	newCNAME := libdns.CNAME{Name: "alias", TTL: 5 * time.Minute, Target: fmt.Sprintf("a1.%s", zone)}
+	newMX := libdns.MX{Name: "@", TTL: 5 * time.Minute, Preference: 5, Target: fmt.Sprintf("mx.%s", zone)}
+	newSRV := libdns.SRV{Service: "ldap", Transport: "tcp", Name: "@", TTL: 5 * time.Minute, Priority: 10, Weight: 20, Port: 389, Target: fmt.Sprintf("ldap.%s", zone)}
+
+	// Unsupported record type that should be skipped without failing.
+	unsupported := libdns.ServiceBinding{Scheme: "https", Name: "@", TTL: 60 * time.Second, Priority: 1, Target: fmt.Sprintf("svc.%s", zone)}
+
+	toAppend := []libdns.Record{newA, newAAAA, newTXT, newCNAME, newMX, newSRV, unsupported}
+
+	added, err := p.AppendRecords(ctx, zone, toAppend)
+	if err != nil {
+		t.Fatalf("AppendRecords returned error for zone %q: %v", zone, err)
+	}
+
+	// We expect all supported records to be added; the unsupported one should be skipped.
+	expectedSupported := []libdns.Record{newA, newAAAA, newTXT, newCNAME, newMX, newSRV}
+	if len(added) != len(expectedSupported) {
+		t.Fatalf("expected %d records to be added; got %d; added=%v", len(expectedSupported), len(added), added)
+	}
+
+	// Verify that each supported record appears in the returned slice
+	for _, expected := range expectedSupported {
+		assertPresent(t, expected, added)
+	}
+
+	// Now fetch all records and ensure our new records are present.
+	all, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		t.Fatalf("GetRecords after append returned error for zone %q: %v", zone, err)
+	}
+
+	for _, expected := range expectedSupported {
+		assertPresent(t, expected, all)
+	}
+
+	// Ensure the unsupported record was not created.
+	assertAbsent(t, unsupported, all)
+
+	// Try adding the same records again. Only types that permit identical records should be added.
+	// In our case, this is TXT, MX, and SRV. The A, AAAA, and CNAME records must be reported as failed.
+	addedAgain, err := p.AppendRecords(ctx, zone, toAppend)
+	var partialErr *PartialFailureError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("expected a PartialFailureError for zone %q; got %v", zone, err)
+	}
+	if len(partialErr.Failed) != 3 {
+		t.Errorf("expected 3 records to fail; got %d: %v", len(partialErr.Failed), partialErr)
+	}
+	if len(addedAgain) != 3 {
+		t.Errorf("expected 3 records to be added; got %d", len(addedAgain))
+	}
+
+	t.Logf("AppendRecords succeeded for zone %q; supported records added and unsupported type skipped", zone)
+}
+
+func scenarioSetRecords_Example1(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+
+	testForZone := func(t *testing.T, zone string, domainID int) {
+		// Ensure original zone has two root A records and a root TXT
+		recordsPriorToSet := []libdns.Record{
+			libdns.Address{Name: "@", IP: netip.MustParseAddr("192.0.2.1"), TTL: 5 * time.Minute},
+			libdns.Address{Name: "@", IP: netip.MustParseAddr("192.0.2.2"), TTL: 5 * time.Minute},
+			libdns.TXT{Name: "@", TTL: 5 * time.Minute, Text: "root-text"},
+		}
+		createDomainRecordsOrDie(t, c, zone, domainID, recordsPriorToSet)
+
+		// Input: Set only one A at root to 192.0.2.3 with TTL 3600.
+		input := []libdns.Record{
+			libdns.Address{Name: "@", TTL: 3600 * time.Second, IP: netip.MustParseAddr("192.0.2.3")},
+		}
+		setRecords, err := p.SetRecords(ctx, zone, input)
+		if err != nil {
+			t.Fatalf("SetRecords returned error: %v", err)
+		}
+
+		// assert one set record
+		if len(setRecords) != 1 {
+			t.Fatalf("expected one set record, got %d", len(setRecords))
+		}
+
+		// Resultant zone: only A @ 192.0.2.3 remains for (Name=@,Type=A); other records unchanged.
+		after, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			t.Fatalf("GetRecords after SetRecords error: %v", err)
+		}
+
+		if len(after) != 2 {
+			t.Fatalf("expected 2 records after SetRecords, got %d", len(after))
+		}
+
+		// Assert that the expected records are present.
+		for _, inputRec := range input {
+			assertPresent(t, inputRec, after)
+		}
+		assertPresent(t, recordsPriorToSet[2], after) // The TXT
+	}
+
+	// Domain without dot suffix
+	zone, domainID := makeTestDomain(t, c)
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone, domainID) })
+
+	// Domain with dot suffix
+	zone, domainID = makeTestDomain(t, c)
+	zone += "."
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone, domainID) })
+}
+
+func scenarioSetRecords_Example2(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+
+	testForZone := func(t *testing.T, zone string, domainID int) {
+		//
+		//	;; Original zone
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::1
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::2
+		//	beta.example.com.  3600 IN AAAA 2001:db8::3
+		//	beta.example.com.  3600 IN AAAA 2001:db8::4
+		//
+		recordsPriorToSet := []libdns.Record{
+			libdns.Address{Name: "alpha", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::1")},
+			libdns.Address{Name: "alpha", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::2")},
+			libdns.Address{Name: "beta", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::3")},
+			libdns.Address{Name: "beta", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::4")},
+		}
+		createDomainRecordsOrDie(t, c, zone, domainID, recordsPriorToSet)
+
+		//
+		//	;; Input
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::1
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::2
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::5
+		//
+		input := []libdns.Record{
+			libdns.Address{Name: "alpha", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::1")},
+			libdns.Address{Name: "alpha", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::2")},
+			libdns.Address{Name: "alpha", TTL: 3600 * time.Second, IP: netip.MustParseAddr("2001:db8::5")},
+		}
+		setRecords, err := p.SetRecords(ctx, zone, input)
+		if err != nil {
+			t.Fatalf("SetRecords returned error: %v", err)
+		}
+		// should have set 3 records for alpha AAAA
+		if len(setRecords) != 3 {
+			t.Fatalf("expected 3 set records, got %d", len(setRecords))
+		}
+
+		//
+		//	;; Resultant zone
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::1
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::2
+		//	alpha.example.com. 3600 IN AAAA 2001:db8::5
+		//	beta.example.com.  3600 IN AAAA 2001:db8::3
+		//	beta.example.com.  3600 IN AAAA 2001:db8::4
+		//
+		after, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			t.Fatalf("GetRecords after SetRecords error: %v", err)
+		}
+
+		// We expect exactly 5 records in the zone now
+		if len(after) != 5 {
+			t.Fatalf("expected 5 records after SetRecords, got %d", len(after))
+		}
+
+		// Assert that expected records are present
+		for _, inputRec := range input {
+			assertPresent(t, inputRec, after)
+		}
+		assertPresent(t, recordsPriorToSet[2], after) // beta ::3
+		assertPresent(t, recordsPriorToSet[3], after) // beta ::4
+	}
+
+	// Domain without dot suffix
+	zone, domainID := makeTestDomain(t, c)
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone, domainID) })
+
+	// Domain with dot suffix
+	zone, domainID = makeTestDomain(t, c)
+	zone += "."
+	t.Run(zone, func(t *testing.T) { testForZone(t, zone, domainID) })
+}
+
+// SetRecords must keep identical records as-is and update changed records in place rather than deleting and
+// recreating them, so the Linode record IDs survive a reconcile.
+func scenarioSetRecords_KeepsRecordIDs(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+
+	zone, domainID := makeTestDomain(t, c)
+	recordsPriorToSet := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.2")},
+	}
+	createDomainRecordsOrDie(t, c, zone, domainID, recordsPriorToSet)
+
+	before, err := c.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		t.Fatalf("failed to list domain records: %v", err)
+	}
+	idsByTarget := make(map[string]int)
+	for _, record := range before {
+		idsByTarget[record.Target] = record.ID
+	}
+
+	// Keep 192.0.2.1 unchanged and replace 192.0.2.2 with 192.0.2.3
+	input := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.3")},
+	}
+	if _, err := p.SetRecords(ctx, zone, input); err != nil {
+		t.Fatalf("SetRecords returned error: %v", err)
+	}
+
+	after, err := c.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		t.Fatalf("failed to list domain records: %v", err)
+	}
+	if len(after) != 2 {
+		t.Fatalf("expected 2 records after SetRecords, got %d", len(after))
+	}
+	for _, record := range after {
+		switch record.Target {
+		case "192.0.2.1":
+			if record.ID != idsByTarget["192.0.2.1"] {
+				t.Errorf("unchanged record was recreated: ID %d, want %d", record.ID, idsByTarget["192.0.2.1"])
+			}
+		case "192.0.2.3":
+			if record.ID != idsByTarget["192.0.2.2"] {
+				t.Errorf("changed record was not updated in place: ID %d, want %d", record.ID, idsByTarget["192.0.2.2"])
+			}
+		default:
+			t.Errorf("unexpected record after SetRecords: %+v", record)
+		}
+	}
+}
+
+// A Transactional SetRecords that fails part way must leave the zone as it was.
+func scenarioSetRecords_TransactionalRollback(t *testing.T, p *Provider, c linodego.Client) {
+	p.Transactional = true
+	ctx := context.Background()
+
+	zone, domainID := makeTestDomain(t, c)
+	recordsPriorToSet := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.10")},
+	}
+	createDomainRecordsOrDie(t, c, zone, domainID, recordsPriorToSet)
+
+	// The A record is updated first, then the CNAME is rejected by Linode because "www" already has an A record.
+	input := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.2")},
+		libdns.CNAME{Name: "www", TTL: 5 * time.Minute, Target: fmt.Sprintf("a1.%s", zone)},
+	}
+	if _, err := p.SetRecords(ctx, zone, input); err == nil {
+		t.Fatalf("expected SetRecords to fail for zone %q", zone)
+	} else if !strings.Contains(err.Error(), "rolled back") {
+		t.Errorf("expected error to report a successful rollback, got: %v", err)
+	}
+
+	after, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		t.Fatalf("GetRecords after SetRecords error: %v", err)
+	}
+	if len(after) != len(recordsPriorToSet) {
+		t.Fatalf("expected %d records after rollback, got %d (%v)", len(recordsPriorToSet), len(after), after)
+	}
+	for _, record := range recordsPriorToSet {
+		assertPresent(t, record, after)
+	}
+	assertAbsent(t, input[0], after)
+}
+
+// Test case replicating a real-world scenario:
+// This replicates what Caddy does when it is working on a DNS-01 challenge.
+// It creates a TXT record with TTL 0, name _acme-challenge.<subdomain>, and zone "<domain>.".
+func scenarioAcmeChallenge_AppendTXT(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+
+	// Domain with dot suffix
+	zone, _ := makeTestDomain(t, c)
+	zone += "."
+	acmeTXT := libdns.TXT{Name: "_acme-challenge.foobar", TTL: 0, Text: "xyz123"}
+
+	added, err := p.AppendRecords(ctx, zone, []libdns.Record{acmeTXT})
+	if err != nil {
+		t.Fatalf("AppendRecords returned error for zone %q: %v", zone, err)
+	}
+	if len(added) != 1 {
+		t.Fatalf("expected exactly 1 record to be added; got %d; added=%v", len(added), added)
+	}
+	// Assert the record we intended to add is in the returned slice
+	assertPresent(t, acmeTXT, added)
+
+	// Optionally verify via GetRecords that it now exists in the zone
+	all, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		t.Fatalf("GetRecords after append returned error for zone %q: %v", zone, err)
+	}
+	assertPresent(t, acmeTXT, all)
+}
+
+// The zone lifecycle methods create, update and delete a domain, keeping settings that an update does not mention.
+func scenarioZoneLifecycle(t *testing.T, p *Provider, c linodego.Client) {
+	ctx := context.Background()
+	domain := fmt.Sprintf("libdns-test-%s-%s.example", time.Now().UTC().Format("20060102-150405"), randHex(4))
+	zone := domain + "."
+
+	created, err := p.CreateZone(ctx, zone, ZoneOptions{
+		SOAEmail:    "hostmaster@" + domain,
+		Description: "created by the libdns-linode tests",
+		TTL:         time.Hour,
+		Tags:        []string{"libdns-test"},
+	})
+	if err != nil {
+		t.Fatalf("CreateZone returned error: %v", err)
+	}
+	if created.Name != domain {
+		t.Errorf("CreateZone returned zone %q, want %q", created.Name, domain)
+	}
+	domains, err := c.ListDomains(ctx, linodego.NewListOptions(0, fmt.Sprintf(`{"domain": %q}`, domain)))
+	if err != nil || len(domains) != 1 {
+		t.Fatalf("ListDomains for the new zone = %v, %v, want exactly one domain", domains, err)
+	}
+	domainID := domains[0].ID
+	t.Cleanup(func() {
+		_ = c.DeleteDomain(context.Background(), domainID)
+	})
+	if d := domains[0]; d.Type != linodego.DomainTypeMaster || d.Status != linodego.DomainStatusActive || d.TTLSec != 3600 {
+		t.Errorf("new domain = %+v, want an active master domain with a TTL of 3600", d)
+	}
+
+	err = p.UpdateZone(ctx, zone, ZoneOptions{
+		Refresh: 4 * time.Hour,
+		Tags:    []string{"libdns-test", "updated"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateZone returned error: %v", err)
+	}
+	updated, err := c.GetDomain(ctx, domainID)
+	if err != nil {
+		t.Fatalf("GetDomain returned error: %v", err)
+	}
+	if updated.RefreshSec != 14400 || len(updated.Tags) != 2 {
+		t.Errorf("updated domain = %+v, want the refresh time and tags changed", updated)
+	}
+	if updated.SOAEmail != "hostmaster@"+domain || updated.Description != "created by the libdns-linode tests" || updated.TTLSec != 3600 {
+		t.Errorf("updated domain = %+v, want the settings not given to UpdateZone kept", updated)
+	}
+
+	if err := p.DeleteZone(ctx, zone); err != nil {
+		t.Fatalf("DeleteZone returned error: %v", err)
+	}
+	if _, err := c.GetDomain(ctx, domainID); err == nil {
+		t.Error("domain still exists after DeleteZone")
+	}
+	if _, err := p.GetRecords(ctx, zone); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("GetRecords after DeleteZone returned %v, want ErrZoneNotFound", err)
+	}
+}
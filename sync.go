@@ -0,0 +1,216 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/libdns/libdns"
+)
+
+// SyncOptions configures SyncZone.
+type SyncOptions struct {
+	// Apply makes SyncZone make the changes it planned. Without it, SyncZone only returns the plan, so that it can be
+	// reviewed before calling SyncZone again with Apply and Reviewed set.
+	Apply bool
+	// Reviewed is the plan returned by an earlier call without Apply. If it is set, SyncZone only applies its plan if
+	// it makes the same changes as Reviewed, and otherwise returns the new plan with ErrPlanChanged, so that records
+	// changed in the meantime are not updated or deleted without being reviewed.
+	Reviewed *SyncPlan
+	// Protected are records SyncZone never changes, such as NS records at the apex or records managed by other tools.
+	// Each entry protects the existing records with its name and, unless its Type is "", its type; its other fields
+	// are ignored. Names are relative to the zone, and desired records must not be protected.
+	Protected []libdns.RR
+}
+
+// RecordUpdate is an existing record that SyncZone overwrites in place.
+type RecordUpdate struct {
+	// From is the existing record.
+	From libdns.Record
+	// To is the desired record it is changed into.
+	To libdns.Record
+}
+
+// SyncPlan lists the changes that make a zone hold exactly the desired records.
+type SyncPlan struct {
+	// Creates are desired records that do not exist yet.
+	Creates []libdns.Record
+	// Updates are existing records that are changed into desired records of the same (Name, Type) pair.
+	Updates []RecordUpdate
+	// Deletes are existing records that are not desired.
+	Deletes []libdns.Record
+	// Unchanged are desired records that already exist as they are.
+	Unchanged []libdns.Record
+	// Protected are existing records left alone because SyncOptions.Protected matches them.
+	Protected []libdns.Record
+	// Applied is set once the changes have been made.
+	Applied bool
+}
+
+// HasChanges reports whether the plan changes the zone.
+func (plan *SyncPlan) HasChanges() bool {
+	return len(plan.Creates) > 0 || len(plan.Updates) > 0 || len(plan.Deletes) > 0
+}
+
+// changes returns the changes of the plan in a canonical form, to compare them with those of another plan.
+func (plan *SyncPlan) changes() []string {
+	key := func(record libdns.Record) string {
+		rr := record.RR()
+		return fmt.Sprintf("%s %d %s %q", asciiName(rr.Name), int(rr.TTL.Seconds()), rr.Type, rr.Data)
+	}
+	changes := make([]string, 0, len(plan.Creates)+len(plan.Updates)+len(plan.Deletes))
+	for _, record := range plan.Creates {
+		changes = append(changes, "create "+key(record))
+	}
+	for _, update := range plan.Updates {
+		changes = append(changes, "update "+key(update.From)+" to "+key(update.To))
+	}
+	for _, record := range plan.Deletes {
+		changes = append(changes, "delete "+key(record))
+	}
+	slices.Sort(changes)
+	return changes
+}
+
+// SyncZone makes the zone hold exactly the desired records, apart from the protected ones. Unlike SetRecords, which
+// only reconciles the (Name, Type) pairs it is given, it also deletes every other record of the zone. It diffs the
+// zone the same way: identical records are kept, changed records are updated in place, and the leftovers are created
+// or deleted. Desired TTLs that Linode does not support are rounded up the way Linode does, so the plan shows the TTLs
+// the records end up with.
+//
+// The plan is always returned, and its changes are only made if opts.Apply is set. To apply a plan only as it was
+// reviewed, pass it in opts.Reviewed: if the zone or the desired records changed so that the plan differs, nothing is
+// changed and the error wraps ErrPlanChanged. If a change fails, the error is returned with the plan, which is then
+// not marked as applied; with Transactional set, the changes already made are rolled back. When ResolveFQDNs routes
+// zone to a name inside a zone, only the records at or below that name are synced.
+func (p *Provider) SyncZone(ctx context.Context, zone string, desired []libdns.Record, opts SyncOptions) (*SyncPlan, error) {
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	route, err := p.routeZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error finding zone of %s: %w", zone, err)
+	}
+	zone = route.zone
+	defer p.lockZone(zone)()
+	p.logger.Debug("Enter SyncZone", "zone", zone, "lenDesired", len(desired), "lenProtected", len(opts.Protected), "apply", opts.Apply)
+
+	protected := make([]libdns.Record, 0, len(opts.Protected))
+	for _, rr := range opts.Protected {
+		protected = append(protected, rr)
+	}
+	protected = route.toZone(protected)
+	isProtected := func(rr libdns.RR) bool {
+		key := rrsetKeyOf(rr)
+		for _, record := range protected {
+			protectedRR := record.RR()
+			if asciiName(protectedRR.Name) == key.name && (protectedRR.Type == "" || protectedRR.Type == key.typ) {
+				return true
+			}
+		}
+		return false
+	}
+	// Plan with the TTLs Linode stores, so that desired records whose TTL it rounds up compare equal to stored ones
+	rounded := make([]libdns.Record, 0, len(desired))
+	for _, record := range desired {
+		rounded = append(rounded, withLinodeTTL(record))
+	}
+	desired = rounded
+	zoneDesired := route.toZone(desired)
+	for i, record := range zoneDesired {
+		rr := record.RR()
+		if rr.Name == "" {
+			return nil, fmt.Errorf("record name is required")
+		}
+		if isProtected(rr) {
+			desiredRR := desired[i].RR()
+			return nil, fmt.Errorf("desired record (%s %s) is protected", desiredRR.Name, desiredRR.Type)
+		}
+	}
+
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	existing, err := p.listAllDomainRecords(ctx, domainID, "")
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return nil, fmt.Errorf("could not list domain records: %w", err)
+	}
+
+	// show returns a record of the zone the way the caller names it
+	show := func(record libdns.Record) libdns.Record {
+		if renamed, ok := route.fromZone(record); ok {
+			record = renamed
+		}
+		return p.presentRecord(record)
+	}
+	syncPlan := &SyncPlan{}
+	for _, linodeRecord := range existing {
+		record, err := convertToLibdns(p.logger, &linodeRecord)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		if _, ok := route.fromZone(record); ok && isProtected(record.RR()) {
+			syncPlan.Protected = append(syncPlan.Protected, show(record))
+		}
+	}
+	plan, err := p.planRecordChanges(existing, zoneDesired, func(rr libdns.RR) bool {
+		_, ok := route.fromZone(rr)
+		return ok && !isProtected(rr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, unchanged := range plan.unchanged {
+		record, err := convertToLibdns(p.logger, &unchanged.existing)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		syncPlan.Unchanged = append(syncPlan.Unchanged, show(record))
+	}
+	for _, update := range plan.updates {
+		record, err := convertToLibdns(p.logger, &update.existing)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		syncPlan.Updates = append(syncPlan.Updates, RecordUpdate{From: show(record), To: desired[update.index]})
+	}
+	for _, index := range plan.creates {
+		syncPlan.Creates = append(syncPlan.Creates, desired[index])
+	}
+	for _, linodeRecord := range plan.deletes {
+		record, err := convertToLibdns(p.logger, &linodeRecord)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert record to libdns struct: %w", err)
+		}
+		syncPlan.Deletes = append(syncPlan.Deletes, show(record))
+	}
+	p.logger.Debug("planned zone sync", "zone", zone, "domainID", domainID, "lenCreates", len(syncPlan.Creates),
+		"lenUpdates", len(syncPlan.Updates), "lenDeletes", len(syncPlan.Deletes), "lenProtected", len(syncPlan.Protected))
+	if !opts.Apply {
+		p.logger.Debug("Exit SyncZone", "zone", zone, "applied", false)
+		return syncPlan, nil
+	}
+
+	if opts.Reviewed != nil && !slices.Equal(syncPlan.changes(), opts.Reviewed.changes()) {
+		p.logger.Debug("Exit SyncZone", "zone", zone, "applied", false, "planChanged", true)
+		return syncPlan, fmt.Errorf("could not sync zone %s: %w", zone, ErrPlanChanged)
+	}
+
+	results, err := p.applyRecordPlanOrRollBack(ctx, zone, domainID, zoneDesired, plan)
+	if err != nil {
+		p.forgetZoneIfGone(zone, err)
+		return syncPlan, fmt.Errorf("could not sync zone %s: %w", zone, err)
+	}
+	// Report the records as Linode stored them
+	for i, update := range plan.updates {
+		syncPlan.Updates[i].To = show(results[update.index])
+	}
+	for i, index := range plan.creates {
+		syncPlan.Creates[i] = show(results[index])
+	}
+	syncPlan.Applied = true
+	p.logger.Debug("Exit SyncZone", "zone", zone, "applied", true)
+	return syncPlan, nil
+}
@@ -0,0 +1,156 @@
+package linode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linode/linodego"
+)
+
+// ValidationReport is the outcome of Validate.
+type ValidationReport struct {
+	// Username is the Linode user the token belongs to.
+	Username string `json:"username,omitempty"`
+	// Restricted is set when the user can only access the resources they were granted.
+	Restricted bool `json:"restricted"`
+	// Scopes are the OAuth scopes of the token, e.g. "*" for all of them or "domains:read_write". They are nil if
+	// Linode did not report them.
+	Scopes []string `json:"scopes,omitempty"`
+	// DomainsAccess is the access to the Domains API allowed by the scopes: linodego.AccessLevelReadWrite,
+	// linodego.AccessLevelReadOnly, or "" for none or when the scopes are unknown.
+	DomainsAccess linodego.GrantPermissionLevel `json:"domains_access,omitempty"`
+	// Zones describes the zones passed to Validate, in the same order.
+	Zones []ZoneAccess `json:"zones,omitempty"`
+	// Problems lists everything that keeps the provider from managing the zones. It is empty if the validation
+	// succeeded.
+	Problems []error `json:"-"`
+	// ProblemMessages are the messages of Problems, in the same order, so that the report can be serialized.
+	ProblemMessages []string `json:"problems,omitempty"`
+}
+
+// ZoneAccess describes the access to a zone found by Validate.
+type ZoneAccess struct {
+	// Zone is the zone as passed to Validate.
+	Zone string `json:"zone"`
+	// DomainID is the Linode domain ID of the zone, or 0 if the zone is not visible to the token.
+	DomainID int `json:"domain_id,omitempty"`
+	// Access is linodego.AccessLevelReadWrite if the provider can manage the zone's records,
+	// linodego.AccessLevelReadOnly if it can only read them, or "" if the zone is not visible or not granted.
+	Access linodego.GrantPermissionLevel `json:"access,omitempty"`
+}
+
+// OK reports whether the validation found no problems.
+func (r *ValidationReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Validate checks that the provider's token works, that its scopes and its user's grants allow managing DNS records,
+// and that each of the zones is visible and writable, so that a misconfiguration can be reported at startup rather
+// than when a record is first changed. It makes a few API requests and changes nothing.
+//
+// The report is returned whenever the checks could be made. If they found problems, the error joins them together, so
+// that errors.Is can match ErrAuthentication, ErrInsufficientPermissions, ErrZoneNotFound and ErrAmbiguousZone.
+func (p *Provider) Validate(ctx context.Context, zones ...string) (*ValidationReport, error) {
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	p.logger.Debug("Enter Validate", "zones", zones)
+	report := &ValidationReport{}
+	profile, scopes, err := p.apiGetProfile(ctx)
+	if err != nil {
+		err = classifyAPIError(err)
+		if !errors.Is(err, ErrAuthentication) {
+			return nil, fmt.Errorf("could not get profile: %w", err)
+		}
+		report.addProblem(fmt.Errorf("the token was rejected: %w", err))
+		return report, report.err()
+	}
+	report.Username = profile.Username
+	report.Restricted = profile.Restricted
+	if scopes != "" {
+		report.Scopes = strings.FieldsFunc(scopes, func(r rune) bool { return r == ' ' || r == ',' })
+		report.DomainsAccess = scopeAccess(report.Scopes, "domains")
+		if report.DomainsAccess != linodego.AccessLevelReadWrite {
+			report.addProblem(fmt.Errorf("%w: the token has scopes %q, which do not include domains:read_write",
+				ErrInsufficientPermissions, scopes))
+		}
+	}
+
+	// Restricted users only have the access to each domain they were granted
+	var granted map[int]linodego.GrantPermissionLevel
+	if profile.Restricted {
+		grants, err := p.apiGetProfileGrants(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not get grants: %w", classifyAPIError(err))
+		}
+		granted = make(map[int]linodego.GrantPermissionLevel)
+		if grants != nil {
+			for _, grant := range grants.Domain {
+				granted[grant.ID] = grant.Permissions
+			}
+		}
+	}
+
+	for _, zone := range zones {
+		zoneAccess := ZoneAccess{Zone: zone}
+		domainID, err := p.getDomainIDByZone(ctx, zone)
+		switch {
+		case err == nil:
+			zoneAccess.DomainID = domainID
+			zoneAccess.Access = linodego.AccessLevelReadWrite
+			if granted != nil {
+				zoneAccess.Access = granted[domainID]
+			}
+			if report.DomainsAccess == linodego.AccessLevelReadOnly {
+				zoneAccess.Access = linodego.AccessLevelReadOnly
+			}
+			if zoneAccess.Access != linodego.AccessLevelReadWrite {
+				report.addProblem(fmt.Errorf("zone %s: %w: the records cannot be changed", zone, ErrInsufficientPermissions))
+			}
+		case errors.Is(err, ErrZoneNotFound), errors.Is(err, ErrAmbiguousZone), errors.Is(err, ErrAuthentication):
+			report.addProblem(fmt.Errorf("zone %s: %w", zone, err))
+		default:
+			return nil, fmt.Errorf("could not look up zone %s: %w", zone, err)
+		}
+		report.Zones = append(report.Zones, zoneAccess)
+	}
+	p.logger.Debug("Exit Validate", "username", report.Username, "lenProblems", len(report.Problems))
+	return report, report.err()
+}
+
+// addProblem records a problem along with its message.
+func (r *ValidationReport) addProblem(err error) {
+	r.Problems = append(r.Problems, err)
+	r.ProblemMessages = append(r.ProblemMessages, err.Error())
+}
+
+// err returns the problems joined together, or nil if there are none.
+func (r *ValidationReport) err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("validation failed: %w", errors.Join(r.Problems...))
+}
+
+// scopeAccess returns the access the OAuth scopes allow to the API of the given resource type, e.g. "domains".
+func scopeAccess(scopes []string, resource string) linodego.GrantPermissionLevel {
+	access := linodego.GrantPermissionLevel("")
+	for _, scope := range scopes {
+		if scope == "*" {
+			return linodego.AccessLevelReadWrite
+		}
+		name, level, ok := strings.Cut(scope, ":")
+		if !ok || name != resource {
+			continue
+		}
+		switch level {
+		case "read_write", "*":
+			return linodego.AccessLevelReadWrite
+		case "read_only":
+			access = linodego.AccessLevelReadOnly
+		}
+	}
+	return access
+}
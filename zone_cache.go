@@ -0,0 +1,97 @@
+package linode
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultZoneCacheTTL is how long the domain ID of a zone is cached when Provider.ZoneCacheTTL is zero.
+const DefaultZoneCacheTTL = 5 * time.Minute
+
+// zoneCacheTTL returns how long zones are cached, or a negative duration if they are not.
+func (p *Provider) zoneCacheTTL() time.Duration {
+	if p.ZoneCacheTTL == 0 {
+		return DefaultZoneCacheTTL
+	}
+	return p.ZoneCacheTTL
+}
+
+type zoneCacheEntry struct {
+	domainID int
+	expires  time.Time
+}
+
+type resolvedNameEntry struct {
+	zone    string
+	expires time.Time
+}
+
+// zoneCache remembers the Linode domain ID of each zone, keyed by the normalized zone name, and the zone found by
+// FindZone for each name. It is safe for concurrent use; the zero value is an empty cache.
+type zoneCache struct {
+	mutex    sync.Mutex
+	entries  map[string]zoneCacheEntry
+	resolved map[string]resolvedNameEntry
+}
+
+// get returns the cached domain ID of the zone, if there is one that has not expired.
+func (c *zoneCache) get(zone string) (int, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[normalizeZone(zone)]
+	if !ok || time.Now().After(entry.expires) {
+		return 0, false
+	}
+	return entry.domainID, true
+}
+
+// put caches the domain ID of the zone for ttl.
+func (c *zoneCache) put(zone string, domainID int, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]zoneCacheEntry)
+	}
+	c.entries[normalizeZone(zone)] = zoneCacheEntry{domainID: domainID, expires: time.Now().Add(ttl)}
+}
+
+// getResolved returns the normalized zone cached for the name, if there is one that has not expired.
+func (c *zoneCache) getResolved(name string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.resolved[normalizeZone(name)]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
+	}
+	return entry.zone, true
+}
+
+// putResolved caches the zone containing the name for ttl.
+func (c *zoneCache) putResolved(name, zone string, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.resolved == nil {
+		c.resolved = make(map[string]resolvedNameEntry)
+	}
+	c.resolved[normalizeZone(name)] = resolvedNameEntry{zone: normalizeZone(zone), expires: time.Now().Add(ttl)}
+}
+
+// invalidate forgets the zone, the names resolved to it, and the names at or below it, which may have been resolved to
+// a parent zone before it was created. If zone is "", every zone and name is forgotten.
+func (c *zoneCache) invalidate(zone string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if zone == "" {
+		c.entries = nil
+		c.resolved = nil
+		return
+	}
+	zone = normalizeZone(zone)
+	delete(c.entries, zone)
+	for name, entry := range c.resolved {
+		if entry.zone == zone || name == zone || strings.HasSuffix(name, "."+zone) {
+			delete(c.resolved, name)
+		}
+	}
+}
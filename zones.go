@@ -0,0 +1,234 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/linode/linodego"
+)
+
+// ZoneOptions describes the settings of a Linode domain. When updating a zone, zero values and nil slices leave the
+// current setting unchanged, while an empty non-nil slice clears it. Linode rounds the durations to the nearest
+// value it supports.
+type ZoneOptions struct {
+	// Type is linodego.DomainTypeMaster, the default when creating a zone, or linodego.DomainTypeSlave.
+	Type linodego.DomainType `json:"type,omitempty"`
+	// Status is linodego.DomainStatusActive, the default when creating a zone, or linodego.DomainStatusDisabled.
+	Status linodego.DomainStatus `json:"status,omitempty"`
+	// SOAEmail is the start of authority email address. It is required for master zones.
+	SOAEmail string `json:"soa_email,omitempty"`
+	// Description is a free-form note about the zone.
+	Description string `json:"description,omitempty"`
+
+	// Refresh, Retry and Expire are the SOA timers of the zone.
+	Refresh time.Duration `json:"refresh,omitempty"`
+	Retry   time.Duration `json:"retry,omitempty"`
+	Expire  time.Duration `json:"expire,omitempty"`
+	// TTL is the default TTL of the records in the zone.
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	// MasterIPs are the IP addresses a slave zone is transferred from. They are required for slave zones.
+	MasterIPs []string `json:"master_ips,omitempty"`
+	// AXFRIPs are the IP addresses allowed to transfer a master zone.
+	AXFRIPs []string `json:"axfr_ips,omitempty"`
+	// Tags are the Linode tags of the zone.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// ZoneDetails describes a Linode domain.
+type ZoneDetails struct {
+	// ID is the Linode domain ID.
+	ID int `json:"id"`
+	// Name is the domain name of the zone, without a trailing dot.
+	Name string `json:"name"`
+	ZoneOptions
+}
+
+// ZoneFilter selects zones in ListZoneDetails. Empty fields match every zone.
+type ZoneFilter struct {
+	// Tags selects zones that have all of these tags.
+	Tags []string `json:"tags,omitempty"`
+	// Status selects zones with this status.
+	Status linodego.DomainStatus `json:"status,omitempty"`
+	// Type selects zones of this type.
+	Type linodego.DomainType `json:"type,omitempty"`
+}
+
+// matches reports whether the zone is selected by the filter.
+func (f ZoneFilter) matches(zone ZoneDetails) bool {
+	if f.Status != "" && zone.Status != f.Status {
+		return false
+	}
+	if f.Type != "" && zone.Type != f.Type {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(zone.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListZoneDetails lists the zones (domains) selected by the filter together with their settings.
+func (p *Provider) ListZoneDetails(ctx context.Context, filter ZoneFilter) ([]ZoneDetails, error) {
+	if err := p.init(ctx); err != nil {
+		return nil, err
+	}
+	p.logger.Debug("Enter ListZoneDetails", "filter", filter)
+	domains, err := p.listAllDomains(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("error listing domains: %w", err)
+	}
+	zones := make([]ZoneDetails, 0, len(domains))
+	for _, domain := range domains {
+		zone := zoneDetailsFromDomain(domain)
+		zone.Name = p.presentName(zone.Name)
+		if filter.matches(zone) {
+			zones = append(zones, zone)
+		}
+	}
+	p.logger.Debug("Exit ListZoneDetails", "lenDomains", len(domains), "lenZones", len(zones))
+	return zones, nil
+}
+
+func zoneDetailsFromDomain(domain linodego.Domain) ZoneDetails {
+	return ZoneDetails{
+		ID:   domain.ID,
+		Name: domain.Domain,
+		ZoneOptions: ZoneOptions{
+			Type:        domain.Type,
+			Status:      domain.Status,
+			SOAEmail:    domain.SOAEmail,
+			Description: domain.Description,
+			Refresh:     time.Duration(domain.RefreshSec) * time.Second,
+			Retry:       time.Duration(domain.RetrySec) * time.Second,
+			Expire:      time.Duration(domain.ExpireSec) * time.Second,
+			TTL:         time.Duration(domain.TTLSec) * time.Second,
+			MasterIPs:   domain.MasterIPs,
+			AXFRIPs:     domain.AXfrIPs,
+			Tags:        domain.Tags,
+		},
+	}
+}
+
+// CreateZone creates a Linode domain for the zone.
+func (p *Provider) CreateZone(ctx context.Context, zone string, opts ZoneOptions) (libdns.Zone, error) {
+	defer p.lockZone(zone)()
+	if err := p.init(ctx); err != nil {
+		return libdns.Zone{}, err
+	}
+	p.logger.Debug("Enter CreateZone", "zone", zone, "type", opts.Type)
+	createOpts := linodego.DomainCreateOptions{
+		Domain:      domainName(zone),
+		Type:        opts.Type,
+		Status:      opts.Status,
+		Description: opts.Description,
+		SOAEmail:    opts.SOAEmail,
+		RetrySec:    int(opts.Retry.Seconds()),
+		MasterIPs:   opts.MasterIPs,
+		AXfrIPs:     opts.AXFRIPs,
+		Tags:        opts.Tags,
+		ExpireSec:   int(opts.Expire.Seconds()),
+		RefreshSec:  int(opts.Refresh.Seconds()),
+		TTLSec:      int(opts.TTL.Seconds()),
+	}
+	if createOpts.Type == "" {
+		createOpts.Type = linodego.DomainTypeMaster
+	}
+	if createOpts.Status == "" {
+		createOpts.Status = linodego.DomainStatusActive
+	}
+	domain, err := p.apiCreateDomain(ctx, createOpts)
+	if err != nil {
+		return libdns.Zone{}, fmt.Errorf("could not create domain for zone %s: %w", zone, classifyAPIError(err))
+	}
+	p.zoneCache.invalidate(zone)
+	p.logger.Debug("Exit CreateZone", "zone", zone, "domainID", domain.ID)
+	return libdns.Zone{Name: p.presentName(domain.Domain)}, nil
+}
+
+// UpdateZone changes the settings of the zone's Linode domain. Settings that are not given in opts are kept.
+func (p *Provider) UpdateZone(ctx context.Context, zone string, opts ZoneOptions) error {
+	defer p.lockZone(zone)()
+	if err := p.init(ctx); err != nil {
+		return err
+	}
+	p.logger.Debug("Enter UpdateZone", "zone", zone)
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	domain, err := p.apiGetDomain(ctx, domainID)
+	if err != nil {
+		err = classifyAPIError(err)
+		p.forgetZoneIfGone(zone, err)
+		return fmt.Errorf("could not get domain %d: %w", domainID, err)
+	}
+
+	// linodego always sends the IP and tag lists, so start from the current settings rather than from scratch
+	updateOpts := domain.GetUpdateOptions()
+	if opts.Type != "" {
+		updateOpts.Type = opts.Type
+	}
+	if opts.Status != "" {
+		updateOpts.Status = opts.Status
+	}
+	if opts.SOAEmail != "" {
+		updateOpts.SOAEmail = opts.SOAEmail
+	}
+	if opts.Description != "" {
+		updateOpts.Description = opts.Description
+	}
+	if opts.Refresh != 0 {
+		updateOpts.RefreshSec = int(opts.Refresh.Seconds())
+	}
+	if opts.Retry != 0 {
+		updateOpts.RetrySec = int(opts.Retry.Seconds())
+	}
+	if opts.Expire != 0 {
+		updateOpts.ExpireSec = int(opts.Expire.Seconds())
+	}
+	if opts.TTL != 0 {
+		updateOpts.TTLSec = int(opts.TTL.Seconds())
+	}
+	if opts.MasterIPs != nil {
+		updateOpts.MasterIPs = opts.MasterIPs
+	}
+	if opts.AXFRIPs != nil {
+		updateOpts.AXfrIPs = opts.AXFRIPs
+	}
+	if opts.Tags != nil {
+		updateOpts.Tags = opts.Tags
+	}
+	if _, err := p.apiUpdateDomain(ctx, domainID, updateOpts); err != nil {
+		err = classifyAPIError(err)
+		p.forgetZoneIfGone(zone, err)
+		return fmt.Errorf("could not update domain %d: %w", domainID, err)
+	}
+	p.logger.Debug("Exit UpdateZone", "zone", zone, "domainID", domainID)
+	return nil
+}
+
+// DeleteZone deletes the zone's Linode domain together with all of its records.
+func (p *Provider) DeleteZone(ctx context.Context, zone string) error {
+	defer p.lockZone(zone)()
+	if err := p.init(ctx); err != nil {
+		return err
+	}
+	p.logger.Debug("Enter DeleteZone", "zone", zone)
+	domainID, err := p.getDomainIDByZone(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("error getting domain ID for zone %s: %w", zone, err)
+	}
+	// Whatever the outcome, the cached domain ID can no longer be trusted
+	p.zoneCache.invalidate(zone)
+	if err := p.apiDeleteDomain(ctx, domainID); err != nil {
+		return fmt.Errorf("could not delete domain %d: %w", domainID, classifyAPIError(err))
+	}
+	p.logger.Debug("Exit DeleteZone", "zone", zone, "domainID", domainID)
+	return nil
+}